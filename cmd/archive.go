@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/toshimaru/nyan/highlight"
+)
+
+// zipMagic is the start of zip archives, either with a file or empty.
+var zipMagic = [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")}
+
+// errStopWalk stops walkArchive without an error.
+var errStopWalk = errors.New("stop walking the archive")
+
+// archiveEntry is a file or directory in an archive.
+type archiveEntry struct {
+	name  string
+	isDir bool
+	// open returns the content of the entry. It's only valid while walking the archive.
+	open func() (io.ReadCloser, error)
+}
+
+// splitArchiveArg splits a FILE argument of the form ARCHIVE:MEMBER or ARCHIVE!/MEMBER.
+// It reports false when arg is not such a form, including when it names an existing file.
+func splitArchiveArg(arg string) (archive, member string, ok bool) {
+	if i := strings.Index(arg, "!/"); i > 0 && isFile(arg[:i]) {
+		return arg[:i], arg[i+2:], true
+	}
+	if isFile(arg) {
+		return "", "", false
+	}
+	if i := strings.LastIndex(arg, ":"); i > 0 && isFile(arg[:i]) {
+		return arg[:i], arg[i+1:], true
+	}
+	return "", "", false
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// fileSize returns the size of a file, or -1 when it isn't a file.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	return info.Size()
+}
+
+// cleanMemberName normalizes the name of an archive member, such as ./src/ to src.
+func cleanMemberName(name string) string {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	return strings.TrimPrefix(name, "/")
+}
+
+// walkArchive calls fn for each entry of a zip archive, or of a tar archive which may be
+// compressed. fn can return errStopWalk to stop walking.
+func walkArchive(archive string, fn func(archiveEntry) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head, r, err := peek(f, len(zipMagic[0]))
+	if err != nil {
+		return err
+	}
+	for _, magic := range zipMagic {
+		if bytes.HasPrefix(head, magic) {
+			return walkZip(f, fn)
+		}
+	}
+
+	in, err := openInput(r, archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return walkTar(in, fn)
+}
+
+func walkZip(f *os.File, fn func(archiveEntry) error) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		err := fn(archiveEntry{name: file.Name, isDir: file.FileInfo().IsDir(), open: file.Open})
+		if errors.Is(err, errStopWalk) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(r io.Reader, fn func(archiveEntry) error) error {
+	tr := tar.NewReader(r)
+	for first := true; ; first = false {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if first {
+				return errors.New("not a zip or tar archive")
+			}
+			return err
+		}
+
+		err = fn(archiveEntry{
+			name:  header.Name,
+			isDir: header.Typeflag == tar.TypeDir,
+			open:  func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		})
+		if errors.Is(err, errStopWalk) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// listArchive prints the names of the archive members, one per line.
+func listArchive(w io.Writer, archive string) error {
+	return walkArchive(archive, func(entry archiveEntry) error {
+		_, err := fmt.Fprintln(w, entry.name)
+		return err
+	})
+}
+
+// printArchiveMember highlights a member of an archive. The language is chosen as if the
+// member was extracted next to the archive.
+func (o *options) printArchiveMember(w io.Writer, archive, member string, opts highlight.Options) error {
+	member = cleanMemberName(member)
+	found := false
+	err := walkArchive(archive, func(entry archiveEntry) error {
+		if entry.isDir || cleanMemberName(entry.name) != member {
+			return nil
+		}
+		found = true
+		o.traceDetect("archive", "%s in %s", member, archive)
+
+		r, err := entry.open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if err := o.printInput(w, r, filepath.Join(filepath.Dir(archive), filepath.FromSlash(member)), opts); err != nil {
+			return err
+		}
+		return errStopWalk
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no member %q", member)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArchiveArg(t *testing.T) {
+	tests := []struct {
+		arg         string
+		wantArchive string
+		wantMember  string
+		wantOK      bool
+	}{
+		{"testdata/archive/sample.zip:src/main.go", "testdata/archive/sample.zip", "src/main.go", true},
+		{"testdata/archive/sample.zip!/src/main.go", "testdata/archive/sample.zip", "src/main.go", true},
+		{"testdata/archive/sample.zip:", "testdata/archive/sample.zip", "", true},
+		{"testdata/archive/sample.zip!/", "testdata/archive/sample.zip", "", true},
+		{"testdata/archive/sample.zip", "", "", false},
+		{"testdata/archive/missing.zip:src/main.go", "", "", false},
+		{"testdata/archive:src/main.go", "", "", false},
+		{"testdata/dummy.go", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			archive, member, ok := splitArchiveArg(tt.arg)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantArchive, archive)
+			assert.Equal(t, tt.wantMember, member)
+		})
+	}
+
+	t.Run("existing file with a colon", func(t *testing.T) {
+		if os.PathSeparator == '\\' {
+			t.Skip("colons can't be used in file names on Windows")
+		}
+		path := filepath.Join(t.TempDir(), "notes:1")
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+		_, _, ok := splitArchiveArg(path)
+
+		assert.False(t, ok)
+	})
+}
+
+func TestCleanMemberName(t *testing.T) {
+	assert.Equal(t, "src/main.go", cleanMemberName("./src/main.go"))
+	assert.Equal(t, "src/main.go", cleanMemberName("/src//main.go"))
+	assert.Equal(t, "src", cleanMemberName("src/"))
+	assert.Equal(t, "", cleanMemberName("/"))
+	assert.Equal(t, "", cleanMemberName(""))
+}
+
+func TestArchiveMembers(t *testing.T) {
+	setupTerminalMock(t)
+
+	for _, archive := range []string{"sample.zip", "sample.tar", "sample.tar.gz"} {
+		path := "testdata/archive/" + archive
+
+		t.Run(archive, func(t *testing.T) {
+			var o, e bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetErr(&e)
+			rootCmd.SetArgs([]string{path + ":src/main.go", path + "!/src/gen.go.gz", path + ":README"})
+			err := rootCmd.Execute()
+
+			assert.NoError(t, err)
+			assert.Empty(t, e.String())
+			assert.Equal(t, 2, strings.Count(o.String(), highlightedGoCode))
+			assert.True(t, strings.HasSuffix(o.String(), "hello\x1b[0m\x1b[38;5;231m\x1b[0m\n"))
+		})
+
+		t.Run(archive+" list", func(t *testing.T) {
+			var o, e bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetErr(&e)
+			rootCmd.SetArgs([]string{path + ":"})
+			err := rootCmd.Execute()
+
+			assert.NoError(t, err)
+			var names []string
+			for _, name := range strings.Fields(o.String()) {
+				names = append(names, cleanMemberName(name))
+			}
+			assert.ElementsMatch(t, []string{"src", "src/gen.go.gz", "src/main.go", "README"}, names)
+		})
+
+		t.Run(archive+" missing member", func(t *testing.T) {
+			var o, e bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetErr(&e)
+			rootCmd.SetArgs([]string{path + ":src/missing.go"})
+			err := rootCmd.Execute()
+
+			assert.Error(t, err)
+			assert.Equal(t, "Error: "+path+": no member \"src/missing.go\"\n", e.String())
+		})
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"testdata/dummy.go:main.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: testdata/dummy.go: not a zip or tar archive\n", e.String())
+	})
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/toshimaru/nyan/highlight"
+)
+
+const (
+	binaryAuto    = "auto"
+	binarySkip    = "skip"
+	binaryRaw     = "raw"
+	binaryHexdump = "hexdump"
+)
+
+var binaryModes = []string{binaryAuto, binarySkip, binaryRaw, binaryHexdump}
+
+// errBinarySkipped is returned for binary content which is not shown.
+var errBinarySkipped = errors.New("binary file is not shown")
+
+func validateBinaryMode(mode string) error {
+	switch mode {
+	case binaryAuto, binarySkip, binaryRaw, binaryHexdump:
+		return nil
+	}
+	return fmt.Errorf("invalid binary mode %q (available: %v)", mode, binaryModes)
+}
+
+// printBinary prints binary content according to --binary. In auto mode, binary content
+// is skipped on a terminal, where its control bytes could mess up the terminal, and in
+// directories, and is written as is otherwise.
+func (o *options) printBinary(w io.Writer, r io.Reader, opts highlight.Options) error {
+	mode := o.binary
+	if mode == binaryAuto {
+		mode = binaryRaw
+		if o.inDir || isTerminalFunc(os.Stdout.Fd()) {
+			mode = binarySkip
+		}
+	}
+	o.traceDetect("binary", "%s", mode)
+	if o.decorator != nil {
+		o.decorator.file.language = "binary"
+	}
+
+	switch mode {
+	case binarySkip:
+		return errBinarySkipped
+	case binaryHexdump:
+		return highlight.Hexdump(w, r, opts)
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// warnBinarySkipped prints the warning for a binary FILE argument which is not shown.
+func warnBinarySkipped(cmd *cobra.Command, arg string) {
+	cmd.PrintErrf("Warning: %s is a binary file (use --binary=hexdump or --binary=raw to show it)\n", arg)
+}
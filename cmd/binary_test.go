@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryOption(t *testing.T) {
+	const binaryFile = "testdata/binary.dat"
+
+	t.Run("skip on terminal", func(t *testing.T) {
+		setupTerminalMock(t)
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{binaryFile, "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Warning: testdata/binary.dat is a binary file (use --binary=hexdump or --binary=raw to show it)\n", e.String())
+		assert.True(t, strings.HasPrefix(o.String(), highlightedGoCode))
+	})
+
+	t.Run("raw when not a terminal", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{binaryFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Equal(t, "\x7fELF\x02\x01\x01\x00hello world\n", o.String())
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		setupTerminalMock(t)
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--binary=raw", binaryFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "\x7fELF\x02\x01\x01\x00hello world\n", o.String())
+	})
+
+	t.Run("hexdump", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--binary=hexdump", "--color=never", binaryFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000  7f 45 4c 46 02 01 01 00  68 65 6c 6c 6f 20 77 6f  |.ELF....hello wo|\n"+
+			"00000010  72 6c 64 0a                                       |rld.|\n", o.String())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		setupTerminalMock(t)
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetIn(strings.NewReader("a\x00b"))
+		rootCmd.SetArgs([]string{})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Warning: stdin is a binary file (use --binary=hexdump or --binary=raw to show it)\n", e.String())
+		assert.Empty(t, o.String())
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--binary=invalid", binaryFile})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid binary mode \"invalid\" (available: [auto skip raw hexdump])\n", e.String())
+	})
+}
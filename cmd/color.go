@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var colorModes = []string{colorAuto, colorAlways, colorNever}
+
+func validateColorMode(mode string) error {
+	switch mode {
+	case colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return fmt.Errorf("invalid color mode %q (available: %v)", mode, colorModes)
+}
+
+// useColor reports whether the output should be highlighted.
+//
+// An explicit --color=always or --color=never wins. In auto mode, a non-empty NO_COLOR
+// disables color, then FORCE_COLOR or CLICOLOR_FORCE (unless set to "0" or "false")
+// enable it, and otherwise color is used only when stdout is a terminal.
+func useColor(mode string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if envForcesColor("FORCE_COLOR") || envForcesColor("CLICOLOR_FORCE") {
+		return true
+	}
+	return isTerminalFunc(os.Stdout.Fd())
+}
+
+func envForcesColor(key string) bool {
+	switch os.Getenv(key) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
+const (
+	depthAuto = "auto"
+	depth8    = "8"
+	depth16   = "16"
+	depth256  = "256"
+	depth16m  = "16m"
+)
+
+var colorDepths = []string{depthAuto, depth8, depth16, depth256, depth16m}
+
+func validateColorDepth(depth string) error {
+	switch depth {
+	case depthAuto, depth8, depth16, depth256, depth16m:
+		return nil
+	}
+	return fmt.Errorf("invalid color depth %q (available: %v)", depth, colorDepths)
+}
+
+// terminalFormatter returns the name of the chroma terminal formatter for the color depth.
+// In auto mode, the depth is detected from COLORTERM and TERM.
+func terminalFormatter(depth string) string {
+	if depth == depthAuto {
+		depth = detectColorDepth()
+	}
+	switch depth {
+	case depth8:
+		return "terminal8"
+	case depth16:
+		return "terminal16"
+	case depth16m:
+		return "terminal16m"
+	}
+	return "terminal256"
+}
+
+// detectColorDepth guesses the number of colors supported by the terminal.
+// It falls back to 256 colors, which nearly all modern terminals support.
+func detectColorDepth() string {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return depth16m
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.HasSuffix(term, "-direct"):
+		return depth16m
+	case strings.Contains(term, "256color"):
+		return depth256
+	case strings.Contains(term, "16color"), term == "linux", term == "cygwin":
+		return depth16
+	case term == "ansi", strings.HasPrefix(term, "vt1"), strings.HasPrefix(term, "vt2"):
+		return depth8
+	}
+	return depth256
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		env      map[string]string
+		terminal bool
+		want     bool
+	}{
+		{name: "auto on terminal", mode: colorAuto, terminal: true, want: true},
+		{name: "auto off terminal", mode: colorAuto, terminal: false, want: false},
+		{name: "always off terminal", mode: colorAlways, terminal: false, want: true},
+		{name: "never on terminal", mode: colorNever, terminal: true, want: false},
+		{name: "NO_COLOR", mode: colorAuto, env: map[string]string{"NO_COLOR": "1"}, terminal: true, want: false},
+		{name: "NO_COLOR with always", mode: colorAlways, env: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "FORCE_COLOR", mode: colorAuto, env: map[string]string{"FORCE_COLOR": "1"}, want: true},
+		{name: "FORCE_COLOR=0", mode: colorAuto, env: map[string]string{"FORCE_COLOR": "0"}, want: false},
+		{name: "CLICOLOR_FORCE", mode: colorAuto, env: map[string]string{"CLICOLOR_FORCE": "1"}, want: true},
+		{name: "FORCE_COLOR with never", mode: colorNever, env: map[string]string{"FORCE_COLOR": "1"}, want: false},
+		{name: "NO_COLOR over FORCE_COLOR", mode: colorAuto, env: map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			originalIsTerminalFunc := isTerminalFunc
+			isTerminalFunc = func(fd uintptr) bool { return tt.terminal }
+			t.Cleanup(func() {
+				isTerminalFunc = originalIsTerminalFunc
+			})
+
+			assert.Equal(t, tt.want, useColor(tt.mode))
+		})
+	}
+}
+
+func TestValidateColorMode(t *testing.T) {
+	for _, mode := range colorModes {
+		assert.NoError(t, validateColorMode(mode))
+	}
+	assert.EqualError(t, validateColorMode("sometimes"), `invalid color mode "sometimes" (available: [auto always never])`)
+}
+
+func TestTerminalFormatter(t *testing.T) {
+	tests := []struct {
+		depth     string
+		colorterm string
+		term      string
+		want      string
+	}{
+		{depth: depth8, colorterm: "truecolor", want: "terminal8"},
+		{depth: depth16, want: "terminal16"},
+		{depth: depth256, want: "terminal256"},
+		{depth: depth16m, term: "vt100", want: "terminal16m"},
+		{depth: depthAuto, colorterm: "truecolor", term: "xterm-256color", want: "terminal16m"},
+		{depth: depthAuto, colorterm: "24bit", want: "terminal16m"},
+		{depth: depthAuto, term: "xterm-direct", want: "terminal16m"},
+		{depth: depthAuto, term: "xterm-256color", want: "terminal256"},
+		{depth: depthAuto, term: "screen-256color", want: "terminal256"},
+		{depth: depthAuto, term: "xterm-16color", want: "terminal16"},
+		{depth: depthAuto, term: "linux", want: "terminal16"},
+		{depth: depthAuto, term: "vt100", want: "terminal8"},
+		{depth: depthAuto, term: "ansi", want: "terminal8"},
+		{depth: depthAuto, term: "xterm", want: "terminal256"},
+		{depth: depthAuto, want: "terminal256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.depth+"/"+tt.colorterm+"/"+tt.term, func(t *testing.T) {
+			t.Setenv("COLORTERM", tt.colorterm)
+			t.Setenv("TERM", tt.term)
+
+			assert.Equal(t, tt.want, terminalFormatter(tt.depth))
+		})
+	}
+}
+
+func TestValidateColorDepth(t *testing.T) {
+	for _, depth := range colorDepths {
+		assert.NoError(t, validateColorDepth(depth))
+	}
+	assert.EqualError(t, validateColorDepth("24"), `invalid color depth "24" (available: [auto 8 16 256 16m])`)
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	sourceDefault     = "default"
+	sourceCommandLine = "command line"
+	sourceEnv         = "NYAN_OPTS"
+)
+
+// configDir returns the directory of nyan's configuration files,
+// $XDG_CONFIG_HOME/nyan or ~/.config/nyan.
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "nyan")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "nyan")
+}
+
+// configPath returns the config file given by --config, or the default one.
+func (o *options) configPath() string {
+	if o.configFile != "" {
+		return o.configFile
+	}
+	if dir := configDir(); dir != "" {
+		return filepath.Join(dir, "config")
+	}
+	return ""
+}
+
+// applyConfig sets the flags which are not given on the command line from NYAN_OPTS,
+// and then from the config file.
+func (o *options) applyConfig(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+	o.sources = map[string]string{}
+
+	envArgs, err := splitArgs(os.Getenv("NYAN_OPTS"))
+	if err != nil {
+		err = fmt.Errorf("%s: %w", sourceEnv, err)
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err := o.mergeFlags(cmd.Flags(), sourceEnv, envArgs); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+
+	path := o.configPath()
+	if path == "" {
+		return nil
+	}
+	fileArgs, err := readConfigFile(path)
+	if errors.Is(err, fs.ErrNotExist) && o.configFile == "" {
+		return nil
+	}
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err := o.mergeFlags(cmd.Flags(), path, fileArgs); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	return nil
+}
+
+// mergeFlags parses args from source, and sets the flags which are not set yet.
+// Values of repeatable flags are put before the ones already set.
+func (o *options) mergeFlags(flags *pflag.FlagSet, source string, args []string) error {
+	fs := pflag.NewFlagSet(source, pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	(&options{}).addFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected argument %q", source, fs.Arg(0))
+	}
+
+	var err error
+	fs.Visit(func(f *pflag.Flag) {
+		target := flags.Lookup(f.Name)
+		if target == nil || err != nil {
+			return
+		}
+		if slice, ok := target.Value.(pflag.SliceValue); ok {
+			values := append(f.Value.(pflag.SliceValue).GetSlice(), slice.GetSlice()...)
+			err = slice.Replace(values)
+			target.Changed = true
+			if _, ok := o.sources[f.Name]; !ok {
+				o.sources[f.Name] = source
+			}
+			return
+		}
+		if target.Changed {
+			return
+		}
+		err = flags.Set(f.Name, f.Value.String())
+		o.sources[f.Name] = source
+	})
+	return err
+}
+
+// readConfigFile reads the flags in a config file. Each line holds flags as on the
+// command line, and a # at the start of an argument begins a comment.
+func readConfigFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var args []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineArgs, err := splitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		args = append(args, lineArgs...)
+	}
+	return args, scanner.Err()
+}
+
+// splitArgs splits s into arguments like a shell does, honoring single and double
+// quotes, backslash escapes, and comments from a # at the start of an argument.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == '#' && !inArg:
+			// The rest is a comment, such as the sources printed by `nyan config`.
+			return args, nil
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func newConfigCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Show the settings merged from the config file, NYAN_OPTS and the command line.",
+		Long: "Show the settings merged from the config file, NYAN_OPTS and the command line.\n" +
+			"The output can be used as a config file.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.printConfig(cmd)
+			return nil
+		},
+	}
+}
+
+// printConfig prints the effective flag values with their sources.
+func (o *options) printConfig(cmd *cobra.Command) {
+	if path := o.configPath(); path != "" {
+		cmd.Printf("# Config file: %s\n", path)
+	}
+
+	fs := pflag.NewFlagSet("config", pflag.ContinueOnError)
+	(&options{}).addFlags(fs)
+	fs.VisitAll(func(f *pflag.Flag) {
+		target := cmd.Flags().Lookup(f.Name)
+		if target == nil {
+			return
+		}
+		source := sourceDefault
+		if target.Changed {
+			source = sourceCommandLine
+			if s, ok := o.sources[f.Name]; ok {
+				source = s
+			}
+		}
+
+		values := []string{target.Value.String()}
+		if slice, ok := target.Value.(pflag.SliceValue); ok {
+			values = slice.GetSlice()
+		}
+		for _, value := range values {
+			if value == "" || strings.ContainsAny(value, " \t\"'\\#") {
+				value = strconv.Quote(value)
+			}
+			cmd.Printf("--%s=%s # %s\n", f.Name, value, source)
+		}
+	})
+}
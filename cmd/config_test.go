@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain keeps the user's config file and NYAN_OPTS away from the tests.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "nyan-config")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	os.Unsetenv("NYAN_OPTS")
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Run("XDG_CONFIG_HOME", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", filepath.FromSlash("/xdg"))
+
+		assert.Equal(t, filepath.FromSlash("/xdg/nyan"), configDir())
+	})
+
+	t.Run("home", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", filepath.FromSlash("/home/nyan"))
+		t.Setenv("USERPROFILE", filepath.FromSlash("/home/nyan"))
+
+		assert.Equal(t, filepath.FromSlash("/home/nyan/.config/nyan"), configDir())
+	})
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"-n --theme=vim", []string{"-n", "--theme=vim"}},
+		{"--theme-file 'my theme.xml'", []string{"--theme-file", "my theme.xml"}},
+		{`--theme-file "my \"theme\".xml"`, []string{"--theme-file", `my "theme".xml`}},
+		{`--theme-file my\ theme.xml`, []string{"--theme-file", "my theme.xml"}},
+		{`-l ''`, []string{"-l", ""}},
+		{"-n\t--color\nnever", []string{"-n", "--color", "never"}},
+		{"--number=true # command line", []string{"--number=true"}},
+		{`--theme=vim#1 "#" '#'`, []string{"--theme=vim#1", "#", "#"}},
+		{"# comment", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := splitArgs(tt.in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("unterminated quote", func(t *testing.T) {
+		_, err := splitArgs(`--theme "vim`)
+
+		assert.EqualError(t, err, `unterminated quote in "--theme \"vim"`)
+	})
+}
+
+func TestConfigFile(t *testing.T) {
+	config := filepath.Join("testdata", "nyan", "config")
+
+	t.Run("from config file", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", config, "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "     1\tpackage main\n")
+		assert.Empty(t, e.String())
+	})
+
+	t.Run("command line wins", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", config, "--number=false", "--color=always", "--paging=never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.NotContains(t, o.String(), "     1\t")
+		assert.Contains(t, o.String(), "\x1b[")
+	})
+
+	t.Run("default config file", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "testdata")
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"config"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "--number=true # "+filepath.Join("testdata", "nyan", "config")+"\n")
+	})
+
+	t.Run("missing config file", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", "testdata/missing-config", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), "Error: open testdata/missing-config")
+		assert.Empty(t, o.String())
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config")
+		assert.NoError(t, os.WriteFile(path, []byte("--unknown\n"), 0o644))
+
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", path, "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.EqualError(t, err, path+": unknown flag: --unknown")
+		assert.Equal(t, "Error: "+path+": unknown flag: --unknown\n", e.String())
+	})
+
+	t.Run("argument in config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config")
+		assert.NoError(t, os.WriteFile(path, []byte("-n main.go\n"), 0o644))
+
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", path, "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.EqualError(t, err, path+`: unexpected argument "main.go"`)
+	})
+}
+
+func TestNyanOpts(t *testing.T) {
+
+	t.Run("from NYAN_OPTS", func(t *testing.T) {
+		t.Setenv("NYAN_OPTS", "-n --color=never")
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "     1\tpackage main\n")
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		t.Setenv("NYAN_OPTS", "--theme=dracula --color=always")
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--config", "testdata/nyan/config", "--color=auto", "config"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "--theme=dracula # NYAN_OPTS\n")
+		assert.Contains(t, o.String(), "--color=auto # command line\n")
+		assert.Contains(t, o.String(), "--number=true # testdata/nyan/config\n")
+		assert.Contains(t, o.String(), "--paging=auto # default\n")
+	})
+
+	t.Run("invalid NYAN_OPTS", func(t *testing.T) {
+		t.Setenv("NYAN_OPTS", "--theme 'vim")
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.EqualError(t, err, `NYAN_OPTS: unterminated quote in "--theme 'vim"`)
+	})
+}
+
+func TestConfigCommandRoundTrip(t *testing.T) {
+	var first bytes.Buffer
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&first)
+	rootCmd.SetArgs([]string{"config", "-n", "-t", "vim", "--highlight-line", "3", "--exclude", "my dir/"})
+	require.NoError(t, rootCmd.Execute())
+
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, first.Bytes(), 0o644))
+	var second bytes.Buffer
+	rootCmd = NewRootCmd()
+	rootCmd.SetOut(&second)
+	rootCmd.SetArgs([]string{"config", "--config", path})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Contains(t, second.String(), "--number=true # "+path+"\n")
+	assert.Contains(t, second.String(), "--theme=vim # "+path+"\n")
+	assert.Contains(t, second.String(), "--highlight-line=3 # "+path+"\n")
+	assert.Contains(t, second.String(), `--exclude="my dir/" # `+path+"\n")
+	// The default values are read back from the file too.
+	assert.Contains(t, second.String(), "--color=auto # "+path+"\n")
+}
+
+func TestConfigCommand(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", filepath.FromSlash("/xdg"))
+
+	var o, e bytes.Buffer
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"config", "--theme-file", "my theme.xml"})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "# Config file: "+filepath.FromSlash("/xdg/nyan/config")+`
+--binary=auto # default
+--color=auto # default
+--color-depth=auto # default
+--debug-detect=false # default
+--encoding=auto # default
+--hyperlink="" # default
+--ignore-case=false # default
+--language="" # default
+--list-languages=false # default
+--list-themes=false # default
+--number=false # default
+--paging=auto # default
+--recursive=false # default
+--style=auto # default
+--theme=monokai # default
+--theme-file="my theme.xml" # command line
+--version=false # default
+`, o.String())
+}
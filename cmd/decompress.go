@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compression is a compression format recognized by its magic bytes.
+type compression struct {
+	name  string
+	magic []byte
+	// valid checks the bytes following the magic bytes. It's optional.
+	valid func(rest []byte) bool
+	// exts are the file extensions of the format, mapped to the extensions of the
+	// decompressed files, such as .tgz to .tar.
+	exts      map[string]string
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+var compressions = []compression{
+	{
+		name:  "gzip",
+		magic: []byte{0x1f, 0x8b},
+		exts:  map[string]string{".gz": "", ".gzip": "", ".tgz": ".tar"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:  "bzip2",
+		magic: []byte("BZh"),
+		// The magic bytes are followed by the block size from 1 to 9.
+		valid: func(rest []byte) bool {
+			return len(rest) > 0 && '1' <= rest[0] && rest[0] <= '9'
+		},
+		exts: map[string]string{".bz2": "", ".bz": "", ".tbz2": ".tar", ".tbz": ".tar"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		name:  "xz",
+		magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		exts:  map[string]string{".xz": "", ".txz": ".tar"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			xr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(xr), nil
+		},
+	},
+	{
+		name:  "zstd",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		exts:  map[string]string{".zst": "", ".zstd": "", ".tzst": ".tar"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		},
+	},
+}
+
+// magicSize is the number of bytes read to recognize compression formats.
+const magicSize = 6
+
+// detectCompression returns the compression format of the data starting with head, or nil.
+func detectCompression(head []byte) *compression {
+	for i := range compressions {
+		c := &compressions[i]
+		if rest, ok := bytes.CutPrefix(head, c.magic); ok && (c.valid == nil || c.valid(rest)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// innerName returns the name of the decompressed file, such as dump.sql for dump.sql.gz.
+// Names without an extension of the format are returned as is.
+func (c *compression) innerName(name string) string {
+	ext := filepath.Ext(name)
+	inner, ok := c.exts[strings.ToLower(ext)]
+	if !ok {
+		return name
+	}
+	return strings.TrimSuffix(name, ext) + inner
+}
+
+// input is the content of a file or stdin, decompressed when it's compressed.
+type input struct {
+	io.Reader
+	// name is the file name used to choose the lexer, without the compression extension.
+	name string
+	// compression is the compression format, or nil.
+	compression *compression
+	closer      io.Closer
+}
+
+// openInput recognizes compressed data in r by its magic bytes, and returns the input
+// reading the decompressed content. Regular files are rewound instead of buffered, so that
+// uncompressed files are still read as files. Data which starts like a compression format
+// but fails to decompress is read as is.
+func openInput(r io.Reader, name string) (*input, error) {
+	head, r, err := peek(r, magicSize)
+	if err != nil {
+		return nil, err
+	}
+	in := &input{Reader: r, name: name, compression: detectCompression(head)}
+	if in.compression == nil {
+		return in, nil
+	}
+
+	rec := &recorder{r: r}
+	raw := &input{Reader: io.MultiReader(&rec.buf, r), name: name}
+	rc, err := in.compression.newReader(rec)
+	if err != nil {
+		return raw, nil
+	}
+	// Decompressors such as bzip2 only check their data on the first read.
+	_, decompressed, err := peek(rc, 1)
+	if err != nil {
+		rc.Close()
+		return raw, nil
+	}
+	rec.stop()
+	in.Reader, in.closer = decompressed, rc
+	in.name = in.compression.innerName(name)
+	// gzip keeps the original file name, which tells the language of compressed stdin.
+	if gr, ok := rc.(*gzip.Reader); ok && name == "" {
+		in.name = gr.Name
+	}
+	return in, nil
+}
+
+// recorder keeps the bytes read from r until it's stopped, so that they can be read again
+// when r turns out not to be compressed.
+type recorder struct {
+	r       io.Reader
+	buf     bytes.Buffer
+	stopped bool
+}
+
+func (rec *recorder) Read(p []byte) (int, error) {
+	n, err := rec.r.Read(p)
+	if !rec.stopped {
+		rec.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+func (rec *recorder) stop() {
+	rec.stopped = true
+	rec.buf = bytes.Buffer{}
+}
+
+func (in *input) Close() error {
+	if in.closer == nil {
+		return nil
+	}
+	return in.closer.Close()
+}
+
+// peek returns up to the first n bytes of r and a reader of the whole content. Regular
+// files are read up to n bytes and rewound, while other inputs such as pipes are sniffed
+// by a single read, which returns what is available, so that a slow producer's output
+// is not held back until n bytes arrive.
+func peek(r io.Reader, n int) ([]byte, io.Reader, error) {
+	if f, ok := r.(*os.File); ok && isRegularFile(f) {
+		head := make([]byte, n)
+		m, err := io.ReadFull(f, head)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		return head[:m], f, nil
+	}
+
+	br := bufio.NewReaderSize(r, max(n, 4096))
+	// Peek(1) fills the buffer by a single read.
+	if _, err := br.Peek(1); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	head, _ := br.Peek(min(n, br.Buffered()))
+	// The peeked bytes are only valid until the next read.
+	return bytes.Clone(head), br, nil
+}
+
+// isRegularFile reports whether f is a regular file, whose content is available without
+// waiting for a producer.
+func isRegularFile(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode().IsRegular()
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInnerName(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		want   string
+	}{
+		{"gzip", "dump.sql.gz", "dump.sql"},
+		{"gzip", "logs/app.log.GZ", "logs/app.log"},
+		{"gzip", "backup.tgz", "backup.tar"},
+		{"gzip", "data", "data"},
+		{"bzip2", "dump.sql.bz2", "dump.sql"},
+		{"xz", "dump.sql.xz", "dump.sql"},
+		{"zstd", "dump.sql.zst", "dump.sql"},
+		{"zstd", "dump.sql.gz", "dump.sql.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *compression
+			for i := range compressions {
+				if compressions[i].name == tt.format {
+					c = &compressions[i]
+				}
+			}
+
+			assert.Equal(t, tt.want, c.innerName(tt.name))
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		f, err := os.Open("testdata/dummy.go")
+		require.NoError(t, err)
+		defer f.Close()
+
+		head, r, err := peek(f, 7)
+		require.NoError(t, err)
+		all, err := io.ReadAll(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "package", string(head))
+		assert.True(t, strings.HasPrefix(string(all), "package main\n"))
+	})
+
+	t.Run("pipe", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.Write([]byte("hi\n"))
+			pw.Write([]byte("bye\n"))
+			pw.Close()
+		}()
+
+		head, r, err := peek(pr, 8000)
+		require.NoError(t, err)
+		all, err := io.ReadAll(r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hi\n", string(head))
+		assert.Equal(t, "hi\nbye\n", string(all))
+	})
+}
+
+func TestOpenInput(t *testing.T) {
+	want, err := os.ReadFile("testdata/dummy.go")
+	require.NoError(t, err)
+
+	for _, ext := range []string{"gz", "bz2", "xz", "zst"} {
+		t.Run(ext, func(t *testing.T) {
+			f, err := os.Open("testdata/compressed/dummy.go." + ext)
+			require.NoError(t, err)
+			defer f.Close()
+
+			in, err := openInput(f, "testdata/compressed/dummy.go."+ext)
+			require.NoError(t, err)
+			defer in.Close()
+			got, err := io.ReadAll(in)
+
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+			assert.Equal(t, "testdata/compressed/dummy.go", in.name)
+			assert.NotNil(t, in.compression)
+		})
+	}
+
+	t.Run("uncompressed file", func(t *testing.T) {
+		f, err := os.Open("testdata/dummy.go")
+		require.NoError(t, err)
+		defer f.Close()
+
+		in, err := openInput(f, "testdata/dummy.go")
+		require.NoError(t, err)
+
+		// The file is rewound rather than buffered.
+		assert.Same(t, f, in.Reader)
+		assert.Nil(t, in.compression)
+		got, _ := io.ReadAll(in)
+		assert.Equal(t, string(want), string(got))
+	})
+
+	t.Run("short input", func(t *testing.T) {
+		in, err := openInput(strings.NewReader("a\n"), "")
+		require.NoError(t, err)
+		got, _ := io.ReadAll(in)
+
+		assert.Equal(t, "a\n", string(got))
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		in, err := openInput(strings.NewReader(""), "")
+		require.NoError(t, err)
+		got, _ := io.ReadAll(in)
+
+		assert.Empty(t, got)
+	})
+
+	t.Run("gzip file name", func(t *testing.T) {
+		var b bytes.Buffer
+		gw := gzip.NewWriter(&b)
+		gw.Name = "main.go"
+		gw.Write(want)
+		gw.Close()
+
+		in, err := openInput(&b, "")
+		require.NoError(t, err)
+
+		assert.Equal(t, "main.go", in.name)
+	})
+
+	t.Run("broken gzip", func(t *testing.T) {
+		in, err := openInput(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}), "")
+		require.NoError(t, err)
+		got, _ := io.ReadAll(in)
+
+		// Data which fails to decompress is read as is.
+		assert.Nil(t, in.compression)
+		assert.Equal(t, []byte{0x1f, 0x8b, 0x00}, got)
+	})
+
+	t.Run("text like bzip2", func(t *testing.T) {
+		in, err := openInput(strings.NewReader("BZhello\n"), "bz.txt")
+		require.NoError(t, err)
+		got, _ := io.ReadAll(in)
+
+		assert.Nil(t, in.compression)
+		assert.Equal(t, "BZhello\n", string(got))
+	})
+
+	t.Run("broken bzip2", func(t *testing.T) {
+		text := "BZh9" + strings.Repeat("not bzip2 data\n", 1000)
+		in, err := openInput(strings.NewReader(text), "bz.txt")
+		require.NoError(t, err)
+		got, _ := io.ReadAll(in)
+
+		assert.Nil(t, in.compression)
+		assert.Equal(t, text, string(got))
+	})
+}
+
+func TestCompressedFiles(t *testing.T) {
+	setupTerminalMock(t)
+
+	for _, ext := range []string{"gz", "bz2", "xz", "zst"} {
+		t.Run(ext, func(t *testing.T) {
+			var o, e bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetErr(&e)
+			rootCmd.SetArgs([]string{"testdata/compressed/dummy.go." + ext})
+			err := rootCmd.Execute()
+
+			assert.NoError(t, err)
+			assert.Empty(t, e.String())
+			assert.Contains(t, o.String(), highlightedGoCode)
+		})
+	}
+
+	t.Run("stdin", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "compressed", "dummy.go.zst"))
+		require.NoError(t, err)
+
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetIn(bytes.NewReader(data))
+		rootCmd.SetArgs([]string{"--debug-detect"})
+		err = rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, e.String(), "  compression:    zstd\n")
+		assert.Contains(t, o.String(), highlightedGoCode)
+	})
+
+	t.Run("broken file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broken.gz")
+		require.NoError(t, os.WriteFile(path, []byte{0x1f, 0x8b, 0x08, 0x00}, 0o644))
+
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{path})
+		err := rootCmd.Execute()
+
+		// A file which fails to decompress is shown as is, like any other binary file.
+		assert.ErrorIs(t, err, errBinarySkipped)
+		assert.Equal(t, "Warning: "+path+" is a binary file (use --binary=hexdump or --binary=raw to show it)\n", e.String())
+	})
+
+	t.Run("text like bzip2", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bz.txt")
+		require.NoError(t, os.WriteFile(path, []byte("BZhello\n"), 0o644))
+
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color=never", path})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Equal(t, "BZhello\n", o.String())
+	})
+}
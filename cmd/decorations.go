@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/toshimaru/nyan/highlight"
+	"golang.org/x/term"
+)
+
+// Components of --style.
+const (
+	styleAuto   = "auto"
+	stylePlain  = "plain"
+	styleHeader = "header"
+	styleGrid   = "grid"
+	styleRule   = "rule"
+)
+
+// defaultWidth is the width of the decorations when the terminal width is unknown.
+const defaultWidth = 80
+
+var (
+	styleComponents = []string{styleAuto, stylePlain, styleHeader, styleGrid, styleRule}
+
+	terminalWidthFunc = terminalWidth
+)
+
+// Token types of the decorations.
+var (
+	decorationName    = chroma.GenericStrong
+	decorationDetails = chroma.Comment
+	decorationLine    = chroma.Comment
+)
+
+// decorations are the components of --style drawn around each file.
+type decorations struct {
+	// header is a line with the file name, size, language and encoding.
+	header bool
+	// grid draws lines around the header and after each file.
+	grid bool
+	// rule draws a line between files.
+	rule bool
+}
+
+// parseStyle parses a comma separated list of --style components. In auto mode,
+// headers are drawn for the files of directories.
+func parseStyle(value string, recursive bool) (decorations, error) {
+	var d decorations
+	for _, component := range strings.Split(value, ",") {
+		switch strings.TrimSpace(component) {
+		case styleAuto:
+			d.header = d.header || recursive
+		case stylePlain:
+		case styleHeader:
+			d.header = true
+		case styleGrid:
+			d.grid = true
+		case styleRule:
+			d.rule = true
+		default:
+			return decorations{}, fmt.Errorf("invalid style %q (available: %v)", component, styleComponents)
+		}
+	}
+	return d, nil
+}
+
+func (d decorations) any() bool {
+	return d.header || d.grid || d.rule
+}
+
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultWidth
+}
+
+// fileInfo is the information of a file shown in its header.
+type fileInfo struct {
+	name string
+	// size is -1 when it's unknown, as for stdin.
+	size     int64
+	language string
+	encoding string
+	// link is the URL of the file for --hyperlink, or empty.
+	link string
+}
+
+// decorator writes the files to w with the decorations of --style, colored by the Style
+// and the Formatter of the highlight options set by the flags.
+//
+// The header of a file is written at its first write, once the language has been
+// detected, or at its end when it's empty.
+type decorator struct {
+	w io.Writer
+	decorations
+	opts  highlight.Options
+	width int
+	// links makes the links of the headers. It's nil without links.
+	links *hyperlinker
+
+	file fileInfo
+	// pending is set while the header of the file is not written yet.
+	pending bool
+	// started is set after the first file.
+	started bool
+	// newline is set when the last write ended with a newline.
+	newline bool
+}
+
+func newDecorator(w io.Writer, d decorations, opts highlight.Options, links *hyperlinker) *decorator {
+	return &decorator{w: w, decorations: d, opts: opts, width: terminalWidthFunc(), links: links}
+}
+
+// begin starts a file. The size is -1 when it's unknown, or when the name isn't the
+// path of a file, which has no link then.
+func (d *decorator) begin(name string, size int64) {
+	if d == nil {
+		return
+	}
+	d.file = fileInfo{name: name, size: size}
+	if d.links != nil && size >= 0 {
+		d.file.link = d.links.url(name, 1)
+	}
+	d.pending = true
+}
+
+// end finishes the file started by begin. A file which failed with err before any
+// output, such as a skipped binary file, is not decorated at all.
+func (d *decorator) end(err error) error {
+	if d == nil {
+		return nil
+	}
+	if d.pending {
+		if err != nil {
+			d.pending = false
+			return nil
+		}
+		if err := d.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if !d.grid {
+		return nil
+	}
+	var tokens []chroma.Token
+	if !d.newline {
+		tokens = append(tokens, chroma.Token{Type: chroma.Text, Value: "\n"})
+	}
+	d.newline = true
+	return d.format(append(tokens, d.line()...))
+}
+
+func (d *decorator) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if d.pending {
+		if err := d.writeHeader(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := d.w.Write(p)
+	if n > 0 {
+		d.newline = p[n-1] == '\n'
+	}
+	return n, err
+}
+
+// writeHeader writes the separator from the previous file and the header of the file.
+func (d *decorator) writeHeader() error {
+	var tokens []chroma.Token
+	if d.started {
+		if !d.newline {
+			tokens = append(tokens, chroma.Token{Type: chroma.Text, Value: "\n"})
+		}
+		switch {
+		case d.grid:
+		case d.rule:
+			tokens = append(tokens, d.line()...)
+		case d.header:
+			tokens = append(tokens, chroma.Token{Type: chroma.Text, Value: "\n"})
+		}
+	}
+	if d.header {
+		if d.grid && !d.started {
+			tokens = append(tokens, d.line()...)
+		}
+		tokens = append(tokens, d.headerTokens()...)
+		if d.grid {
+			tokens = append(tokens, d.line()...)
+		}
+	}
+	d.pending = false
+	d.started = true
+	d.newline = true
+	return d.format(tokens)
+}
+
+// headerTokens returns the header line of the file, cut to the width.
+func (d *decorator) headerTokens() []chroma.Token {
+	var details []string
+	if d.file.size >= 0 {
+		details = append(details, formatSize(d.file.size))
+	}
+	for _, detail := range []string{d.file.language, d.file.encoding} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+
+	name := d.file.name
+	var rest string
+	if len(details) > 0 {
+		rest = " (" + strings.Join(details, ", ") + ")"
+	}
+	if width := utf8.RuneCountInString(name); width > d.width {
+		name, rest = truncate(name, d.width), ""
+	} else if width+utf8.RuneCountInString(rest) > d.width {
+		rest = truncate(rest, d.width-width)
+	}
+	if d.file.link != "" {
+		name = highlight.Hyperlink(d.file.link, name)
+	}
+	return []chroma.Token{
+		{Type: decorationName, Value: name},
+		{Type: decorationDetails, Value: rest},
+		{Type: chroma.Text, Value: "\n"},
+	}
+}
+
+// line returns a horizontal line across the width.
+func (d *decorator) line() []chroma.Token {
+	return []chroma.Token{
+		{Type: decorationLine, Value: strings.Repeat("─", d.width)},
+		{Type: chroma.Text, Value: "\n"},
+	}
+}
+
+// format writes the tokens with the colors of the style, or as is without colors.
+func (d *decorator) format(tokens []chroma.Token) error {
+	if d.opts.Plain {
+		for _, token := range tokens {
+			if _, err := io.WriteString(d.w, token.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return formatters.Get(d.opts.Formatter).Format(d.w, d.opts.Style, chroma.Literator(tokens...))
+}
+
+// truncate cuts s to width runes, ending with an ellipsis when it's cut.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// formatSize formats a size in bytes for humans, such as 1.5 KiB.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exp])
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		value     string
+		recursive bool
+		want      decorations
+	}{
+		{"auto", false, decorations{}},
+		{"auto", true, decorations{header: true}},
+		{"plain", true, decorations{}},
+		{"header", false, decorations{header: true}},
+		{"header,grid", false, decorations{header: true, grid: true}},
+		{"rule, header", false, decorations{header: true, rule: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseStyle(tt.value, tt.recursive)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseStyle("header,box", false)
+
+		assert.EqualError(t, err, `invalid style "box" (available: [auto plain header grid rule])`)
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "0 B", formatSize(0))
+	assert.Equal(t, "1023 B", formatSize(1023))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "2.0 MiB", formatSize(2<<20))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "main.go", truncate("main.go", 7))
+	assert.Equal(t, "mai…", truncate("main.go", 4))
+	assert.Equal(t, "", truncate("main.go", 0))
+}
+
+func TestStyleOption(t *testing.T) {
+	setupTerminalWidth(t, 20)
+	files := []string{"testdata/dummy.go", "testdata/nyan/config"}
+	const header1 = "testdata/dummy.go (…\n"
+	const header2 = "testdata/nyan/config\n"
+
+	t.Run("header", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs(append([]string{"--style=header", "--color=never"}, files...))
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), header1+"package main\n"))
+		assert.Contains(t, o.String(), "}\n\n"+header2)
+	})
+
+	t.Run("grid", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs(append([]string{"--style=header,grid", "--color=never"}, files...))
+		err := rootCmd.Execute()
+
+		line := strings.Repeat("─", 20) + "\n"
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), line+header1+line+"package main\n"))
+		assert.Contains(t, o.String(), "}\n"+line+header2+line)
+		assert.True(t, strings.HasSuffix(o.String(), line))
+	})
+
+	t.Run("rule", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs(append([]string{"--style=rule", "--color=never"}, files...))
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), "package main\n"))
+		assert.Contains(t, o.String(), "}\n"+strings.Repeat("─", 20)+"\n# Settings for the tests.\n")
+	})
+
+	t.Run("colors", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--style=header", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), "\x1b[1m\x1b[38;5;231mtestdata/dummy.go\x1b[0m\x1b[38;5;242m (…\x1b[0m"))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		setupTerminalWidth(t, 80)
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetIn(strings.NewReader("package main\n"))
+		rootCmd.SetArgs([]string{"--style=header", "--color=never", "-l", "go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "STDIN (Go, UTF-8)\npackage main\n", o.String())
+	})
+
+	t.Run("invalid style", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--style=box", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid style \"box\" (available: [auto plain header grid rule])\n", e.String())
+	})
+}
+
+func setupTerminalWidth(t *testing.T, width int) {
+	t.Helper()
+	original := terminalWidthFunc
+	terminalWidthFunc = func() int { return width }
+	t.Cleanup(func() { terminalWidthFunc = original })
+}
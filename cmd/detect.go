@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toshimaru/nyan/highlight"
+)
+
+// maxCandidates is the maximum number of candidate lexers shown by --debug-detect.
+const maxCandidates = 5
+
+// traceDetect prints a stage of the language detection for --debug-detect.
+func (o *options) traceDetect(stage, format string, args ...any) {
+	if o.detectLog == nil {
+		return
+	}
+	fmt.Fprintf(o.detectLog, "  %-15s %s\n", stage+":", fmt.Sprintf(format, args...))
+}
+
+// detectionTracer returns a highlight.Options.OnDetect function printing the stages
+// for --debug-detect. It's nil when --debug-detect isn't given.
+func (o *options) detectionTracer() func(highlight.Detection) {
+	if o.detectLog == nil {
+		return nil
+	}
+	return func(d highlight.Detection) {
+		var b strings.Builder
+		switch {
+		case d.Stage == highlight.StageContent || d.Stage == highlight.StageFallback:
+		case d.Input == "":
+			b.WriteString("none")
+		default:
+			b.WriteString(d.Input)
+			if d.Lexer == nil {
+				b.WriteString(": ")
+				if d.Stage == highlight.StageFilename {
+					b.WriteString("no match")
+				} else {
+					b.WriteString("unknown language")
+				}
+			}
+		}
+
+		if len(d.Candidates) > 0 {
+			if b.Len() > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString("(candidates: ")
+			for i, c := range d.Candidates {
+				if i == maxCandidates {
+					fmt.Fprintf(&b, ", ... %d more", len(d.Candidates)-i)
+					break
+				}
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(c.Lexer.Config().Name)
+				if d.Stage == highlight.StageContent {
+					fmt.Fprintf(&b, " %.2f", c.Score)
+				}
+			}
+			b.WriteString(")")
+		} else if d.Stage == highlight.StageContent {
+			b.WriteString("no candidates")
+		}
+
+		if d.Lexer != nil {
+			if b.Len() > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString("=> " + d.Lexer.Config().Name)
+		}
+		o.traceDetect(d.Stage, "%s", b.String())
+	}
+}
+
+// startDetectTrace prints the header of the stages of the detection for name.
+func (o *options) startDetectTrace(name string) {
+	if o.detectLog != nil {
+		fmt.Fprintf(o.detectLog, "%s:\n", name)
+	}
+}
+
+// traceCompression prints the compression format of a compressed input.
+func (o *options) traceCompression(in *input) {
+	if in.compression == nil {
+		return
+	}
+	if in.name == "" {
+		o.traceDetect("compression", "%s", in.compression.name)
+		return
+	}
+	o.traceDetect("compression", "%s => %s", in.compression.name, in.name)
+}
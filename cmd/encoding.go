@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/toshimaru/nyan/highlight"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const encodingAuto = "auto"
+
+// textEncoding is the character encoding of an input.
+type textEncoding struct {
+	name string
+	// decoder decodes the input to UTF-8. It's nil for UTF-8 input without a BOM.
+	decoder encoding.Encoding
+	// utf8 is true when the input is UTF-8, so that it may be binary content.
+	utf8 bool
+}
+
+var (
+	encodingUTF8     = textEncoding{name: "UTF-8", utf8: true}
+	encodingUTF8BOM  = textEncoding{name: "UTF-8 with BOM", decoder: unicode.UTF8BOM, utf8: true}
+	encodingUTF16    = textEncoding{name: "UTF-16", decoder: unicode.UTF16(unicode.BigEndian, unicode.UseBOM)}
+	encodingUTF16LE  = textEncoding{name: "UTF-16LE", decoder: unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)}
+	encodingUTF16BE  = textEncoding{name: "UTF-16BE", decoder: unicode.UTF16(unicode.BigEndian, unicode.UseBOM)}
+	encodingShiftJIS = textEncoding{name: "Shift_JIS", decoder: japanese.ShiftJIS}
+	encodingEUCJP    = textEncoding{name: "EUC-JP", decoder: japanese.EUCJP}
+	encodingLatin1   = textEncoding{name: "ISO-8859-1", decoder: charmap.ISO8859_1}
+)
+
+// encodings are the encodings of --encoding by name. Other names are looked up in the
+// IANA character sets.
+var encodings = map[string]textEncoding{
+	"utf-8":      encodingUTF8BOM,
+	"utf8":       encodingUTF8BOM,
+	"utf-16":     encodingUTF16,
+	"utf-16le":   encodingUTF16LE,
+	"utf-16be":   encodingUTF16BE,
+	"shift_jis":  encodingShiftJIS,
+	"sjis":       encodingShiftJIS,
+	"cp932":      encodingShiftJIS,
+	"euc-jp":     encodingEUCJP,
+	"latin-1":    encodingLatin1,
+	"latin1":     encodingLatin1,
+	"iso-8859-1": encodingLatin1,
+}
+
+var encodingNames = []string{encodingAuto, "utf-8", "utf-16le", "utf-16be", "shift_jis", "euc-jp", "latin-1"}
+
+// lookupEncoding returns the encoding named by --encoding.
+func lookupEncoding(name string) (textEncoding, error) {
+	name = strings.ToLower(name)
+	if e, ok := encodings[name]; ok {
+		return e, nil
+	}
+	if enc, err := ianaindex.IANA.Encoding(name); err == nil && enc != nil {
+		canonical, _ := ianaindex.IANA.Name(enc)
+		return textEncoding{name: canonical, decoder: enc}, nil
+	}
+	return textEncoding{}, fmt.Errorf("unknown encoding %q (e.g. %v)", name, encodingNames)
+}
+
+func validateEncoding(name string) error {
+	if name == encodingAuto {
+		return nil
+	}
+	_, err := lookupEncoding(name)
+	return err
+}
+
+// inputEncoding returns the encoding of the input starting with head, given by --encoding
+// or detected from head.
+func (o *options) inputEncoding(head []byte) textEncoding {
+	if o.encoding != encodingAuto {
+		// Validated by run.
+		e, _ := lookupEncoding(o.encoding)
+		o.traceDetect("encoding", "%s (--encoding)", e.name)
+		return e
+	}
+	e := detectEncoding(head)
+	if e.decoder != nil {
+		o.traceDetect("encoding", "%s", e.name)
+	}
+	return e
+}
+
+// detectEncoding guesses the encoding of the input starting with head by its byte order
+// mark, and then by the byte sequences valid in UTF-16, UTF-8, EUC-JP and Shift_JIS, which
+// have to look like Japanese text.
+// Input which is none of them is taken as ISO-8859-1, unless it looks like binary content,
+// which is reported as UTF-8.
+func detectEncoding(head []byte) textEncoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xef, 0xbb, 0xbf}):
+		return encodingUTF8BOM
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return encodingUTF16LE
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return encodingUTF16BE
+	}
+
+	if e, ok := detectUTF16(head); ok {
+		return e
+	}
+	if utf8.Valid(trimPartialRune(head)) {
+		return encodingUTF8
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return encodingUTF8
+	}
+	if mostlyUTF8(trimPartialRune(head)) {
+		return encodingUTF8
+	}
+	// Kanji in Shift_JIS often start with bytes below 0xa1, which are invalid in EUC-JP,
+	// while EUC-JP text is mostly valid Shift_JIS as half-width katakana. So EUC-JP is tried first.
+	if likelyEUCJP(head) {
+		return encodingEUCJP
+	}
+	if likelyShiftJIS(head) {
+		return encodingShiftJIS
+	}
+	if highlight.IsBinary(head) {
+		return encodingUTF8
+	}
+	return encodingLatin1
+}
+
+// trimPartialRune trims a UTF-8 sequence cut at the end of head.
+func trimPartialRune(head []byte) []byte {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(head); i++ {
+		if utf8.RuneStart(head[len(head)-i]) {
+			if !utf8.FullRune(head[len(head)-i:]) {
+				return head[:len(head)-i]
+			}
+			break
+		}
+	}
+	return head
+}
+
+// mostlyUTF8 reports whether head has valid multibyte UTF-8 sequences, and no more
+// invalid bytes than bytes in them, as UTF-8 text with a few stray bytes has.
+func mostlyUTF8(head []byte) bool {
+	var valid, invalid int
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+		case size > 1:
+			valid += size
+		}
+		head = head[size:]
+	}
+	return valid > 0 && invalid <= valid
+}
+
+// detectUTF16 detects UTF-16 without a byte order mark by its ASCII characters, whose NUL
+// bytes are at odd offsets in UTF-16LE and at even offsets in UTF-16BE. Control characters
+// other than whitespace are taken as a sign of binary content.
+func detectUTF16(head []byte) (textEncoding, bool) {
+	pairs := len(head) / 2
+	// The whole input is in head when it's shorter, and UTF-16 can't have an odd length.
+	if pairs == 0 || len(head)%2 != 0 && len(head) < highlight.BinarySniffSize {
+		return textEncoding{}, false
+	}
+	var le, be int
+	leText, beText := true, true
+	for i := 0; i+1 < len(head); i += 2 {
+		if head[i+1] == 0 {
+			le++
+			leText = leText && isTextByte(head[i])
+		}
+		if head[i] == 0 {
+			be++
+			beText = beText && isTextByte(head[i+1])
+		}
+	}
+	switch {
+	case leText && le*10 >= pairs*4 && be*10 < pairs:
+		return textEncoding{name: "UTF-16LE", decoder: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)}, true
+	case beText && be*10 >= pairs*4 && le*10 < pairs:
+		return textEncoding{name: "UTF-16BE", decoder: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)}, true
+	}
+	return textEncoding{}, false
+}
+
+// isTextByte reports whether the ASCII byte c is a printable character or whitespace.
+func isTextByte(c byte) bool {
+	return c >= 0x20 || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// likelyEUCJP reports whether b consists of valid EUC-JP sequences, most of whose two-byte
+// characters are full-width punctuation, kana or first level kanji. Latin-1 text is valid
+// EUC-JP where its accented letters come in pairs, but they rarely make such characters.
+// A sequence cut at the end of b is ignored.
+func likelyEUCJP(b []byte) bool {
+	var chars, japanese int
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			continue
+		case c == 0x8e: // half-width katakana
+			if i+1 < len(b) && !inRange(b[i+1], 0xa1, 0xdf) {
+				return false
+			}
+			chars++
+			japanese++
+			i++
+		case c == 0x8f: // JIS X 0212
+			for j := 1; j <= 2; j++ {
+				if i+j < len(b) && !inRange(b[i+j], 0xa1, 0xfe) {
+					return false
+				}
+			}
+			chars++
+			i += 2
+		case inRange(c, 0xa1, 0xfe):
+			if i+1 < len(b) && !inRange(b[i+1], 0xa1, 0xfe) {
+				return false
+			}
+			chars++
+			// Punctuation, hiragana, katakana and first level kanji.
+			if c == 0xa1 || c == 0xa4 || c == 0xa5 || inRange(c, 0xb0, 0xcf) {
+				japanese++
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return likelyJapanese(chars, japanese)
+}
+
+// likelyShiftJIS reports whether b consists of valid Shift_JIS sequences, most of whose
+// two-byte characters are full-width punctuation or kana, or end with a byte above ASCII.
+// Latin-1 text is valid Shift_JIS where its accented letters are followed by ASCII letters,
+// which make rare kanji. A sequence cut at the end of b is ignored.
+func likelyShiftJIS(b []byte) bool {
+	var chars, japanese int
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < 0x80, inRange(c, 0xa1, 0xdf):
+			continue
+		case inRange(c, 0x81, 0x9f), inRange(c, 0xe0, 0xfc):
+			if i+1 < len(b) {
+				trail := b[i+1]
+				if !inRange(trail, 0x40, 0x7e) && !inRange(trail, 0x80, 0xfc) {
+					return false
+				}
+				chars++
+				// Punctuation, hiragana and katakana, and most kanji.
+				if inRange(c, 0x81, 0x83) || trail >= 0x80 {
+					japanese++
+				}
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return likelyJapanese(chars, japanese)
+}
+
+// likelyJapanese reports whether japanese out of chars two-byte characters are enough
+// evidence of Japanese text.
+func likelyJapanese(chars, japanese int) bool {
+	return japanese > 0 && japanese*2 >= chars
+}
+
+func inRange(c, lo, hi byte) bool {
+	return lo <= c && c <= hi
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"ascii", []byte("package main\n"), "UTF-8"},
+		{"utf-8", []byte("こんにちは\n"), "UTF-8"},
+		{"utf-8 cut in a rune", []byte("こんにちは")[:14], "UTF-8"},
+		{"utf-8 bom", []byte("\xef\xbb\xbfpackage main\n"), "UTF-8 with BOM"},
+		{"utf-16le bom", []byte("\xff\xfep\x00\n\x00"), "UTF-16LE"},
+		{"utf-16be bom", []byte("\xfe\xff\x00p\x00\n"), "UTF-16BE"},
+		{"utf-16le", []byte("p\x00k\x00g\x00\n\x00"), "UTF-16LE"},
+		{"utf-16be", []byte("\x00p\x00k\x00g\x00\n"), "UTF-16BE"},
+		{"odd length", []byte("a\x00b"), "UTF-8"},
+		{"control characters", []byte("\x00\x01\x00\x02"), "UTF-8"},
+		{"shift_jis", []byte("\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n"), "Shift_JIS"},
+		{"euc-jp", []byte("\xa4\xb3\xa4\xf3\xa4\xcb\xa4\xc1\xa4\xcf\n"), "EUC-JP"},
+		{"latin-1", []byte("caf\xe9 cr\xe8me\n"), "ISO-8859-1"},
+		{"utf-8 with a stray byte", []byte("h\xc3\xa9llo w\xc3\xb6rld \xff"), "UTF-8"},
+		{"latin-1 like shift_jis", []byte("Fran\xe7ais et caf\xe9s\n"), "ISO-8859-1"},
+		{"latin-1 like euc-jp", []byte("\xabDAS \xc4\xd6L\xbb, sagte \xc4rger.\n"), "ISO-8859-1"},
+		{"latin-1 with a utf-8 sequence", []byte("caf\xe9 cr\xe8me br\xfbl\xe9e \xc3\xa9"), "ISO-8859-1"},
+		{"binary", []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00"), "UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectEncoding(tt.input).name)
+		})
+	}
+}
+
+func TestEncodingOption(t *testing.T) {
+	const decoded = "def greet():\n    print(\"こんにちは\")\n"
+
+	for _, file := range []string{"utf8bom.py", "utf16le.py", "sjis.py", "eucjp.py"} {
+		t.Run(file, func(t *testing.T) {
+			var o, e bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetErr(&e)
+			rootCmd.SetArgs([]string{"--color=never", "testdata/encoding/" + file})
+			err := rootCmd.Execute()
+
+			assert.NoError(t, err)
+			assert.Empty(t, e.String())
+			assert.Equal(t, decoded, o.String())
+		})
+	}
+
+	t.Run("highlight", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--color=always", "testdata/encoding/utf16le.py"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "\x1b[38;5;81mdef\x1b[0m")
+		assert.Contains(t, o.String(), "\x1b[38;5;186mこんにちは\x1b[0m")
+	})
+
+	t.Run("latin-1", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--color=never", "--encoding=latin-1", "testdata/encoding/latin1.txt"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "café crème\n", o.String())
+	})
+
+	t.Run("latin-1 detected", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--color=never", "testdata/encoding/latin1-words.txt"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Français et cafés\nGrüße aus Köln\n", o.String())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetIn(bytes.NewBufferString("\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n"))
+		rootCmd.SetArgs([]string{"--color=never", "--encoding=sjis"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "こんにちは\n", o.String())
+	})
+
+	t.Run("utf-8 with a stray byte", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetIn(bytes.NewBufferString("h\xc3\xa9llo w\xc3\xb6rld \xff"))
+		rootCmd.SetArgs([]string{"--color=never"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "h\xc3\xa9llo w\xc3\xb6rld \xff", o.String())
+	})
+
+	t.Run("debug detect", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--debug-detect", "testdata/encoding/sjis.py"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, e.String(), "testdata/encoding/sjis.py:\n  encoding:       Shift_JIS\n")
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--encoding=invalid", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: unknown encoding \"invalid\" (e.g. [auto utf-8 utf-16le utf-16be shift_jis euc-jp latin-1])\n", e.String())
+	})
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// Values of set and unset attributes.
+const (
+	attrSet   = "true"
+	attrUnset = "false"
+)
+
+// plainTextLanguage is the language of files shown without highlighting.
+const plainTextLanguage = "plaintext"
+
+// attributeRule is a line of a .gitattributes file.
+type attributeRule struct {
+	pattern gitPattern
+	// attrs are the attribute values by name. An empty value leaves the attribute
+	// unspecified, as `!attr` does.
+	attrs map[string]string
+}
+
+// attributeFile is a parsed .gitattributes file.
+type attributeFile struct {
+	// dir is the directory the patterns are relative to.
+	dir   string
+	rules []attributeRule
+}
+
+// readAttributeFile reads a gitattributes(5) file. Macro definitions are ignored.
+func readAttributeFile(path, dir string) (*attributeFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &attributeFile{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[attr]") {
+			continue
+		}
+
+		pattern, rest := cutPattern(line)
+		p, err := compileGitPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		rule := attributeRule{pattern: p, attrs: map[string]string{}}
+		for _, attr := range strings.Fields(rest) {
+			switch {
+			case strings.HasPrefix(attr, "-"):
+				rule.attrs[attr[1:]] = attrUnset
+			case strings.HasPrefix(attr, "!"):
+				rule.attrs[attr[1:]] = ""
+			default:
+				name, value, ok := strings.Cut(attr, "=")
+				if !ok {
+					value = attrSet
+				}
+				rule.attrs[name] = value
+			}
+		}
+		file.rules = append(file.rules, rule)
+	}
+	return file, scanner.Err()
+}
+
+// cutPattern splits a .gitattributes line into its pattern, which may be quoted, and the attributes.
+func cutPattern(line string) (pattern, rest string) {
+	if strings.HasPrefix(line, `"`) {
+		if prefix, err := strconv.QuotedPrefix(line); err == nil {
+			pattern, _ = strconv.Unquote(prefix)
+			return pattern, line[len(prefix):]
+		}
+	}
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], line[i:]
+}
+
+// findGitRoot returns the working tree root containing dir and its git directory,
+// or empty strings when dir isn't in a git repository.
+func findGitRoot(dir string) (root, gitDir string) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dir, dotGit
+			}
+			// Worktrees and submodules have a .git file pointing to the git directory.
+			if data, err := os.ReadFile(dotGit); err == nil {
+				if path, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:"); ok {
+					path = strings.TrimSpace(path)
+					if !filepath.IsAbs(path) {
+						path = filepath.Join(dir, path)
+					}
+					return dir, path
+				}
+			}
+			return dir, ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitAttributes returns the attributes of filename given by the .gitattributes files
+// of its repository and $GIT_DIR/info/attributes, as git check-attr does.
+// The files are cached by path.
+func (o *options) gitAttributes(filename string) (map[string]string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	root, gitDir := findGitRoot(filepath.Dir(abs))
+	if root == "" {
+		return nil, nil
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+
+	// From the lowest precedence to the highest: the .gitattributes of the root, those
+	// of the subdirectories down to the file, and $GIT_DIR/info/attributes.
+	paths := [][2]string{{filepath.Join(root, ".gitattributes"), root}}
+	dir := root
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		paths = append(paths, [2]string{filepath.Join(dir, ".gitattributes"), dir})
+	}
+	if gitDir != "" {
+		paths = append(paths, [2]string{filepath.Join(gitDir, "info", "attributes"), root})
+	}
+
+	attrs := map[string]string{}
+	for _, path := range paths {
+		file, err := o.attributeFile(path[0], path[1])
+		if err != nil {
+			return nil, err
+		}
+		if file == nil {
+			continue
+		}
+		rel, err := filepath.Rel(file.dir, abs)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range file.rules {
+			if !rule.pattern.match(rel, false) {
+				continue
+			}
+			for name, value := range rule.attrs {
+				if value == "" {
+					delete(attrs, name)
+				} else {
+					attrs[name] = value
+				}
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// attributeFile returns the cached attribute file at path, or nil when it doesn't exist.
+func (o *options) attributeFile(path, dir string) (*attributeFile, error) {
+	if file, ok := o.attributeFiles[path]; ok {
+		return file, nil
+	}
+	if o.attributeFiles == nil {
+		o.attributeFiles = map[string]*attributeFile{}
+	}
+	file, err := readAttributeFile(path, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.attributeFiles[path] = file
+	return file, nil
+}
+
+// linguistLanguage returns the language of filename given by the linguist-language
+// attribute. Files which are not linguist-detectable, or are linguist-generated, are shown
+// as plain text. It returns "" when the attributes don't tell the language.
+func (o *options) linguistLanguage(filename string) (string, error) {
+	const stage = ".gitattributes"
+	attrs, err := o.gitAttributes(filename)
+	if err != nil {
+		return "", err
+	}
+	if attrs == nil {
+		o.traceDetect(stage, "not in a git repository")
+		return "", nil
+	}
+	if language := attrs["linguist-language"]; language != "" && language != attrSet && language != attrUnset {
+		// Linguist names like `Emacs-Lisp` may stand for `Emacs Lisp`.
+		for _, name := range []string{language, strings.ReplaceAll(language, "-", " ")} {
+			if lexer := lexers.Get(name); lexer != nil {
+				o.traceDetect(stage, "linguist-language=%s", language)
+				return lexer.Config().Name, nil
+			}
+		}
+		o.traceDetect(stage, "linguist-language=%s: unknown language", language)
+	}
+	if attrs["linguist-detectable"] == attrUnset {
+		o.traceDetect(stage, "-linguist-detectable")
+		return plainTextLanguage, nil
+	}
+	if attrs["linguist-generated"] == attrSet {
+		o.traceDetect(stage, "linguist-generated")
+		return plainTextLanguage, nil
+	}
+	o.traceDetect(stage, "no linguist attributes")
+	return "", nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupGitRepo creates a working tree with the given files, and returns its root.
+func setupGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git", "info"), 0o755))
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+func TestFindGitRoot(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{"src/main.go": ""})
+
+	t.Run("git directory", func(t *testing.T) {
+		gotRoot, gotGitDir := findGitRoot(filepath.Join(root, "src"))
+
+		assert.Equal(t, root, gotRoot)
+		assert.Equal(t, filepath.Join(root, ".git"), gotGitDir)
+	})
+
+	t.Run("git file", func(t *testing.T) {
+		worktree := filepath.Join(root, "worktree")
+		require.NoError(t, os.MkdirAll(worktree, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: ../.git/worktrees/w\n"), 0o644))
+
+		gotRoot, gotGitDir := findGitRoot(worktree)
+
+		assert.Equal(t, worktree, gotRoot)
+		assert.Equal(t, filepath.Join(root, ".git", "worktrees", "w"), gotGitDir)
+	})
+
+	t.Run("no repository", func(t *testing.T) {
+		gotRoot, _ := findGitRoot(t.TempDir())
+
+		assert.Empty(t, gotRoot)
+	})
+}
+
+func TestGitAttributes(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		".gitattributes": `# Attributes for the tests.
+[attr]php linguist-language=PHP
+*.inc linguist-language=PHP text
+*.gen linguist-generated
+"with space.txt" diff=plain
+lib/*.inc -text
+`,
+		"lib/.gitattributes": `*.inc linguist-language=Perl !text
+`,
+		".git/info/attributes": `lib/override.inc linguist-language=Ruby
+`,
+	})
+
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{"page.inc", map[string]string{"linguist-language": "PHP", "text": "true"}},
+		{"src/page.inc", map[string]string{"linguist-language": "PHP", "text": "true"}},
+		{"lib/page.inc", map[string]string{"linguist-language": "Perl"}},
+		{"lib/override.inc", map[string]string{"linguist-language": "Ruby"}},
+		{"api.gen", map[string]string{"linguist-generated": "true"}},
+		{"with space.txt", map[string]string{"diff": "plain"}},
+		{"main.go", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := (&options{}).gitAttributes(filepath.Join(root, filepath.FromSlash(tt.path)))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("outside of repository", func(t *testing.T) {
+		got, err := (&options{}).gitAttributes(filepath.Join(t.TempDir(), "page.inc"))
+
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
+
+func TestLinguistLanguage(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		".gitattributes": `*.inc linguist-language=PHP
+*.el.txt linguist-language=Emacs-Lisp
+*.unknown linguist-language=NoSuchLanguage
+*.min.js linguist-generated=true
+*.sql -linguist-detectable
+*.go linguist-detectable=true
+`,
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"page.inc", "PHP"},
+		{"init.el.txt", "EmacsLisp"},
+		{"app.unknown", ""},
+		{"app.min.js", "plaintext"},
+		{"schema.sql", "plaintext"},
+		{"main.go", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := (&options{}).linguistLanguage(filepath.Join(root, tt.path))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGitAttributesOption(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		".gitattributes": "*.inc linguist-language=PHP\n",
+		".nyanrc":        "legacy.inc:text\n",
+		"page.inc":       "<?php echo 'hello';\n",
+		"legacy.inc":     "<?php echo 'hello';\n",
+	})
+
+	var o, e bytes.Buffer
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--color=always", filepath.Join(root, "page.inc"), filepath.Join(root, "legacy.inc")})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Empty(t, e.String())
+	assert.Equal(t, 1, bytes.Count(o.Bytes(), []byte("\x1b[38;5;81mecho\x1b[0m")))
+}
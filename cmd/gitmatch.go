@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// gitPattern is a path pattern of .gitattributes and .gitignore files.
+type gitPattern struct {
+	re *regexp.Regexp
+	// basename is set for patterns without a slash, which match the base name at any depth.
+	basename bool
+	// dirOnly is set for patterns with a trailing slash, which match directories only.
+	dirOnly bool
+}
+
+// compileGitPattern compiles a pattern as described in gitignore(5).
+func compileGitPattern(pattern string) (gitPattern, error) {
+	var p gitPattern
+	if strings.HasSuffix(pattern, "/") {
+		p.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if !strings.Contains(pattern, "/") {
+		p.basename = true
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return gitPattern{}, fmt.Errorf("empty pattern")
+	}
+
+	re, err := regexp.Compile("^" + globRegexp(pattern) + "$")
+	if err != nil {
+		return gitPattern{}, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// match reports whether the slash separated path, relative to the directory of the
+// pattern's file, matches the pattern.
+func (p gitPattern) match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.basename {
+		path = path[strings.LastIndex(path, "/")+1:]
+	}
+	return p.re.MatchString(path)
+}
+
+// globRegexp translates a glob with `*`, `?`, `[...]` and `**` into a regular expression.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				end := i + 2
+				if (i == 0 || glob[i-1] == '/') && (end == len(glob) || glob[end] == '/') {
+					if end == len(glob) {
+						// A trailing "/**" matches everything inside.
+						b.WriteString(".*")
+					} else {
+						// "**/" matches zero or more directories.
+						b.WriteString("(?:.*/)?")
+					}
+					i = end
+					continue
+				}
+				i++
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
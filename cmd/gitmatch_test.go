@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.inc", "page.inc", false, true},
+		{"*.inc", "lib/page.inc", false, true},
+		{"*.inc", "page.inc.bak", false, false},
+		{"page.?nc", "page.inc", false, true},
+		{"page.[ij]nc", "page.jnc", false, true},
+		{"page.[!i]nc", "page.inc", false, false},
+		{"/page.inc", "page.inc", false, true},
+		{"/page.inc", "lib/page.inc", false, false},
+		{"lib/*.inc", "lib/page.inc", false, true},
+		{"lib/*.inc", "lib/sub/page.inc", false, false},
+		{"lib/*.inc", "src/lib/page.inc", false, false},
+		{"**/lib/*.inc", "src/lib/page.inc", false, true},
+		{"**/lib/*.inc", "lib/page.inc", false, true},
+		{"lib/**", "lib/sub/page.inc", false, true},
+		{"lib/**/*.inc", "lib/page.inc", false, true},
+		{"lib/**/*.inc", "lib/a/b/page.inc", false, true},
+		{"lib**.inc", "lib/page.inc", false, false},
+		{`page\*.inc`, "page*.inc", false, true},
+		{`page\*.inc`, "pages.inc", false, false},
+		{"vendor/", "vendor", true, true},
+		{"vendor/", "vendor", false, false},
+		{"[unclosed", "[unclosed", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			p, err := compileGitPattern(tt.pattern)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, p.match(tt.path, tt.isDir))
+		})
+	}
+}
@@ -30,7 +30,8 @@ type goldenTestCase struct {
 func TestGoldenOutput(t *testing.T) {
 	var tests []goldenTestCase
 
-	// Add test cases for all themes
+	// Add test cases for all built-in themes. User themes are never registered, so the
+	// registry holds the built-in styles only.
 	for _, themeName := range styles.Names() {
 		tests = append(tests, goldenTestCase{
 			name: themeName,
@@ -38,6 +39,14 @@ func TestGoldenOutput(t *testing.T) {
 		})
 	}
 
+	// Add test cases for all color depths
+	for _, depth := range []string{depth8, depth16, depth256, depth16m} {
+		tests = append(tests, goldenTestCase{
+			name: "monokai-depth" + depth,
+			args: []string{"--theme", "monokai", "--color-depth", depth, testDataFile},
+		})
+	}
+
 	// Add line-numbered output test
 	tests = append(tests, goldenTestCase{
 		name: "monokai-numbered",
@@ -59,13 +68,12 @@ func runNyanAndCapture(t *testing.T, args []string) string {
 
 	// Mock terminal to enable highlighting
 	setupTerminalMock(t)
-	t.Cleanup(resetStrings)
-	t.Cleanup(resetFlags)
 
 	var o, e bytes.Buffer
-	rootCmd.SetArgs(args)
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs(args)
 	rootCmd.SetIn(nil)
 	err := rootCmd.Execute()
 
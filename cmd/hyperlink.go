@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultHyperlink is the URL template of --hyperlink given without a value.
+const defaultHyperlink = "file://{host}{path}"
+
+// hyperlinker makes the URLs of the OSC 8 hyperlinks of --hyperlink.
+type hyperlinker struct {
+	// template is a URL with the {path}, {line} and {host} placeholders.
+	template string
+	host     string
+}
+
+// newHyperlinker returns a hyperlinker for the --hyperlink template.
+func newHyperlinker(template string) (*hyperlinker, error) {
+	if !strings.Contains(template, "{path}") {
+		return nil, fmt.Errorf("invalid hyperlink template %q (it has no {path}, e.g. %q)", template, "vscode://file/{path}:{line}")
+	}
+	h := &hyperlinker{template: template}
+	if strings.Contains(template, "{host}") {
+		// The host name tells the terminal whether the file is on the same machine.
+		h.host, _ = os.Hostname()
+	}
+	return h, nil
+}
+
+// url returns the URL of a line of a file.
+func (h *hyperlinker) url(path string, line int) string {
+	return lineURL(h.fileURL(path), line)
+}
+
+// fileURL returns the template with the {path} and {host} of a file filled in, which only
+// leaves {line} to fill in for each line.
+func (h *hyperlinker) fileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath()
+	template := h.template
+	if strings.HasPrefix(path, "/") && !strings.Contains(template, "//{path}") {
+		// vscode://file/{path} for /src/main.go is vscode://file/src/main.go, while
+		// file://{path} is file:///src/main.go.
+		template = strings.ReplaceAll(template, "/{path}", "{path}")
+	}
+	// {line} is left as is, as the escaped path and the host name can't contain it.
+	return strings.NewReplacer(
+		"{path}", path,
+		"{host}", h.host,
+	).Replace(template)
+}
+
+// lineURL fills in the {line} of a URL made by fileURL.
+func lineURL(fileURL string, line int) string {
+	return strings.ReplaceAll(fileURL, "{line}", strconv.Itoa(line))
+}
+
+// lineLink returns the highlight.Options.LineLink function of a file.
+func (h *hyperlinker) lineLink(path string) func(int) string {
+	if h == nil {
+		return nil
+	}
+	fileURL := h.fileURL(path)
+	return func(line int) string {
+		return lineURL(fileURL, line)
+	}
+}
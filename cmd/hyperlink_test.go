@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperlinkerURL(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     string
+	}{
+		{"file://{host}{path}", "/src/main.go", "file://host/src/main.go"},
+		{"file://{path}", "/src/my file.go", "file:///src/my%20file.go"},
+		{"vscode://file/{path}:{line}", "/src/main.go", "vscode://file/src/main.go:12"},
+		{"idea://open?file={path}&line={line}", "/src/main.go", "idea://open?file=/src/main.go&line=12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			h, err := newHyperlinker(tt.template)
+			require.NoError(t, err)
+			h.host = "host"
+
+			assert.Equal(t, tt.want, h.url(tt.path, 12))
+		})
+	}
+
+	t.Run("relative path", func(t *testing.T) {
+		h, err := newHyperlinker("file://{path}")
+		require.NoError(t, err)
+		abs, err := filepath.Abs("testdata/dummy.go")
+		require.NoError(t, err)
+
+		assert.Equal(t, "file://"+filepath.ToSlash(abs), h.url("testdata/dummy.go", 1))
+	})
+
+	t.Run("line link", func(t *testing.T) {
+		h, err := newHyperlinker("vscode://file/{path}:{line}")
+		require.NoError(t, err)
+		link := h.lineLink("/src/{line}.go")
+
+		assert.Equal(t, "vscode://file/src/%7Bline%7D.go:1", link(1))
+		assert.Equal(t, "vscode://file/src/%7Bline%7D.go:42", link(42))
+	})
+
+	t.Run("no path", func(t *testing.T) {
+		_, err := newHyperlinker("vscode://file")
+
+		assert.EqualError(t, err, `invalid hyperlink template "vscode://file" (it has no {path}, e.g. "vscode://file/{path}:{line}")`)
+	})
+}
+
+func TestHyperlinkOption(t *testing.T) {
+	abs, err := filepath.Abs("testdata/dummy.go")
+	require.NoError(t, err)
+	link := func(url, text string) string {
+		return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+	}
+
+	t.Run("line numbers", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--hyperlink=vscode://file/{path}:{line}", "-n", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), link("vscode://file"+abs+":1", "     1")+"\t"+highlightedGoCode))
+		assert.Contains(t, o.String(), link("vscode://file"+abs+":2", "     2"))
+	})
+
+	t.Run("header", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--hyperlink", "--style=header", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "\x1b]8;;file://")
+		assert.Contains(t, o.String(), abs+"\x1b\\testdata/dummy.go\x1b]8;;\x1b\\")
+	})
+
+	t.Run("without colors", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--hyperlink", "--style=header", "-n", "--color=never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.NotContains(t, o.String(), "\x1b")
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--hyperlink=file://", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid hyperlink template \"file://\" (it has no {path}, e.g. \"vscode://file/{path}:{line}\")\n", e.String())
+	})
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/spf13/cobra"
+)
+
+func newLanguagesCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "languages [flags] [FILTER]",
+		Short: "List languages available for syntax highlighting.",
+		Long: "List languages available for syntax highlighting, with their aliases, file name patterns and MIME types.\n" +
+			"FILTER narrows the list down to languages whose name, aliases, file name patterns or MIME types contain it.",
+		Example: `$ nyan languages
+$ nyan languages python
+$ nyan languages --json '*.tmpl'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := ""
+			if len(args) > 0 {
+				filter = args[0]
+			}
+			if asJSON {
+				return printLanguagesJSON(cmd.OutOrStdout(), filter)
+			}
+			return printLanguages(cmd.OutOrStdout(), filter)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output in JSON")
+	return cmd
+}
+
+// languageInfo describes a lexer.
+type languageInfo struct {
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases"`
+	Filenames []string `json:"filenames"`
+	MimeTypes []string `json:"mime_types"`
+}
+
+// languages returns the registered lexers matching filter, sorted by name.
+func languages(filter string) []languageInfo {
+	filter = strings.ToLower(filter)
+	out := []languageInfo{}
+	for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+		config := lexer.Config()
+		info := languageInfo{
+			Name:      config.Name,
+			Aliases:   nonNil(config.Aliases),
+			Filenames: nonNil(append(append([]string{}, config.Filenames...), config.AliasFilenames...)),
+			MimeTypes: nonNil(config.MimeTypes),
+		}
+		if filter == "" || info.matches(filter) {
+			out = append(out, info)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+func (l languageInfo) matches(filter string) bool {
+	for _, values := range [][]string{{l.Name}, l.Aliases, l.Filenames, l.MimeTypes} {
+		for _, value := range values {
+			if strings.Contains(strings.ToLower(value), filter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func printLanguages(w io.Writer, filter string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	io.WriteString(tw, "NAME\tALIASES\tFILENAMES\tMIME TYPES\n")
+	for _, l := range languages(filter) {
+		io.WriteString(tw, strings.Join([]string{
+			l.Name,
+			strings.Join(l.Aliases, ","),
+			strings.Join(l.Filenames, ","),
+			strings.Join(l.MimeTypes, ","),
+		}, "\t")+"\n")
+	}
+	return tw.Flush()
+}
+
+func printLanguagesJSON(w io.Writer, filter string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(languages(filter))
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguages(t *testing.T) {
+	t.Run("all", func(t *testing.T) {
+		all := languages("")
+
+		assert.Greater(t, len(all), 100)
+		for i := 1; i < len(all); i++ {
+			assert.LessOrEqual(t, strings.ToLower(all[i-1].Name), strings.ToLower(all[i].Name))
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		for _, filter := range []string{"GO", "golang", "*.go", "text/x-gosrc"} {
+			var names []string
+			for _, l := range languages(filter) {
+				names = append(names, l.Name)
+			}
+			assert.Contains(t, names, "Go", filter)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		assert.Empty(t, languages("no-such-language"))
+	})
+}
+
+func TestLanguagesCommand(t *testing.T) {
+	var o, e bytes.Buffer
+
+	t.Run("table", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"languages", "golang"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		lines := strings.Split(strings.TrimSpace(o.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Regexp(t, `^NAME\s+ALIASES\s+FILENAMES\s+MIME TYPES$`, lines[0])
+		assert.Regexp(t, `^Go\s+go,golang\s+\*\.go\s+text/x-gosrc$`, lines[1])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"languages", "--json", "golang"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		var got []languageInfo
+		require.NoError(t, json.Unmarshal(o.Bytes(), &got))
+		assert.Equal(t, []languageInfo{{
+			Name:      "Go",
+			Aliases:   []string{"go", "golang"},
+			Filenames: []string{"*.go"},
+			MimeTypes: []string{"text/x-gosrc"},
+		}}, got)
+	})
+
+	t.Run("json without match", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"languages", "--json", "no-such-language"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "[]\n", o.String())
+	})
+
+	t.Run("list-languages flag", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--list-languages"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "MIME TYPES")
+		assert.Regexp(t, `\nGo\s+go,golang\s`, o.String())
+	})
+
+	t.Run("list-languages flag with filter", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"-L", "python"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "Python")
+		assert.NotRegexp(t, `\nGo\s`, o.String())
+	})
+
+	t.Run("list-languages flag with filters", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"-L", "python", "go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Empty(t, o.String())
+		assert.Equal(t, "Error: --list-languages accepts at most one filter, received 2\n", e.String())
+	})
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/toshimaru/nyan/highlight"
+)
+
+// parseLineRange parses a --line-range value: START:END, START: or :END, or a single line.
+func parseLineRange(value string) (highlight.LineRange, error) {
+	invalid := fmt.Errorf("invalid line range %q (e.g. 30:60, 30: or :60)", value)
+	start, end, ok := strings.Cut(value, ":")
+	if !ok {
+		end = start
+	}
+
+	var r highlight.LineRange
+	for _, bound := range []struct {
+		s string
+		n *int
+	}{{start, &r.Start}, {end, &r.End}} {
+		if bound.s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(bound.s)
+		if err != nil || n < 1 {
+			return highlight.LineRange{}, invalid
+		}
+		*bound.n = n
+	}
+	if r == (highlight.LineRange{}) || r.End != 0 && r.Start > r.End {
+		return highlight.LineRange{}, invalid
+	}
+	return r, nil
+}
+
+func parseLineRanges(values []string) ([]highlight.LineRange, error) {
+	ranges := make([]highlight.LineRange, 0, len(values))
+	for _, value := range values {
+		r, err := parseLineRange(value)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/toshimaru/nyan/highlight"
+)
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		value string
+		want  highlight.LineRange
+	}{
+		{"30:60", highlight.LineRange{Start: 30, End: 60}},
+		{"30:", highlight.LineRange{Start: 30}},
+		{":60", highlight.LineRange{End: 60}},
+		{"30", highlight.LineRange{Start: 30, End: 30}},
+		{"30:30", highlight.LineRange{Start: 30, End: 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLineRange(tt.value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, value := range []string{"", ":", "0:10", "60:30", "a:b", "-1", "1:2:3"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			_, err := parseLineRange(value)
+
+			assert.EqualError(t, err, "invalid line range \""+value+"\" (e.g. 30:60, 30: or :60)")
+		})
+	}
+}
+
+func TestLineRangeOption(t *testing.T) {
+	t.Run("ranges", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--line-range", "3:4", "--line-range", "9:", "--color=never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "import (\n\t\"fmt\"\n}\n", o.String())
+	})
+
+	t.Run("number", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--line-range", "8:8", "-n", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "     8\t\x1b[38;5;231m\t\x1b[0m\x1b[38;5;148mfmt\x1b[0m\x1b[38;5;231m.\x1b[0m\x1b[38;5;148mPrintln\x1b[0m"+
+			"\x1b[38;5;231m(\x1b[0m\x1b[38;5;186m\"Hello World\"\x1b[0m\x1b[38;5;231m)\x1b[0m\x1b[38;5;231m\x1b[0m\n", o.String())
+	})
+
+	t.Run("number without colors", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--line-range", "1:2", "-n", "--color=never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "     1\tpackage main\n     2\t\n", o.String())
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--line-range", "60:30", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid line range \"60:30\" (e.g. 30:60, 30: or :60)\n", e.String())
+	})
+}
+
+func TestHighlightLineOption(t *testing.T) {
+	t.Run("number", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--highlight-line", "1", "-n", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "     1\t\x1b[38;5;197m\x1b[48;5;237mpackage\x1b[0m")
+		assert.Contains(t, o.String(), "\x1b[38;5;148m\x1b[48;5;237mmain\x1b[0m\x1b[38;5;231m\x1b[48;5;237m\x1b[K\x1b[0m")
+		assert.Contains(t, o.String(), "     2\t\x1b[38;5;231m\x1b[0m\n")
+	})
+
+	t.Run("line ranges", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"-H", "3:4", "--line-range", "4:5", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), "\x1b[38;5;231m\x1b[48;5;237m\t\x1b[0m"))
+		assert.Equal(t, 1, strings.Count(o.String(), "\x1b[K"))
+	})
+
+	t.Run("without colors", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"--highlight-line", "1:3", "--color=never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), "package main\n\nimport (\n"))
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--highlight-line", "0", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid line range \"0\" (e.g. 30:60, 30: or :60)\n", e.String())
+	})
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	pagingAuto   = "auto"
+	pagingAlways = "always"
+	pagingNever  = "never"
+
+	defaultPager = "less -RFX"
+
+	// pagerIdle is how long held back output waits for more before the pager is started.
+	pagerIdle = 200 * time.Millisecond
+)
+
+var (
+	pagingModes = []string{pagingAuto, pagingAlways, pagingNever}
+
+	// errPagerClosed is returned for the output after the pager has quit, so that the rest
+	// of the input isn't read in vain. It ends the command without an error.
+	errPagerClosed = errors.New("pager closed")
+
+	terminalHeightFunc = terminalHeight
+)
+
+func validatePagingMode(mode string) error {
+	switch mode {
+	case pagingAuto, pagingAlways, pagingNever:
+		return nil
+	}
+	return fmt.Errorf("invalid paging mode %q (available: %v)", mode, pagingModes)
+}
+
+// pagerCommand returns the pager command line taken from $NYAN_PAGER, $PAGER or the default.
+// An empty result means paging is disabled.
+func pagerCommand() []string {
+	command, ok := os.LookupEnv("NYAN_PAGER")
+	if !ok {
+		command, ok = os.LookupEnv("PAGER")
+	}
+	if !ok {
+		command = defaultPager
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	// A bare `less` would show the escape sequences instead of colors.
+	if len(args) == 1 && strings.TrimSuffix(filepath.Base(args[0]), ".exe") == "less" {
+		args = strings.Fields(defaultPager)
+	}
+	return args
+}
+
+func terminalHeight() int {
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return height
+}
+
+// pagerWriter sends output to a pager process.
+//
+// In auto mode, output is held back until it no longer fits the terminal, and is written
+// to out directly if the pager turns out not to be needed. Output which stops coming for
+// idle, such as that of a slow stream, starts the pager right away instead of waiting
+// for the rest of the screen.
+type pagerWriter struct {
+	out    io.Writer
+	stdout io.Writer
+	args   []string
+	height int
+	idle   time.Duration
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	buf     bytes.Buffer
+	lines   int
+	started bool
+	failed  bool
+	closed  bool
+	proc    *exec.Cmd
+	stdin   io.WriteCloser
+}
+
+// newPagerWriter returns a pagerWriter for the paging mode, or nil when paging is not used.
+func newPagerWriter(mode string, out io.Writer) *pagerWriter {
+	if mode == pagingNever {
+		return nil
+	}
+	args := pagerCommand()
+	if args == nil {
+		return nil
+	}
+
+	w := &pagerWriter{
+		out:    out,
+		stdout: os.Stdout,
+		args:   args,
+	}
+	if mode == pagingAuto {
+		if !isTerminalFunc(os.Stdout.Fd()) {
+			return nil
+		}
+		if w.height = terminalHeightFunc(); w.height <= 0 {
+			return nil
+		}
+		w.idle = pagerIdle
+	}
+	return w
+}
+
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.failed:
+		return w.out.Write(p)
+	case w.closed:
+		return 0, errPagerClosed
+	case w.started:
+		return w.writePager(p)
+	}
+
+	w.buf.Write(p)
+	w.lines += bytes.Count(p, []byte{'\n'})
+	if w.height > 0 && w.lines < w.height {
+		w.wait()
+		return len(p), nil
+	}
+	return len(p), w.flush()
+}
+
+// wait (re)starts the idle timer of the held back output.
+func (w *pagerWriter) wait() {
+	if w.idle <= 0 {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.idle, w.flushIdle)
+		return
+	}
+	w.timer.Reset(w.idle)
+}
+
+// flushIdle starts the pager with the held back output once the output has stalled.
+func (w *pagerWriter) flushIdle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.started || w.failed || w.closed || w.buf.Len() == 0 {
+		return
+	}
+	w.flush()
+}
+
+// flush starts the pager and sends it the held back output.
+func (w *pagerWriter) flush() error {
+	if err := w.start(); err != nil {
+		// Without a working pager, fall back to the normal output.
+		w.failed = true
+		_, err = w.out.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	_, err := w.writePager(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *pagerWriter) start() error {
+	w.proc = exec.Command(w.args[0], w.args[1:]...)
+	w.proc.Stdout = w.stdout
+	w.proc.Stderr = os.Stderr
+
+	stdin, err := w.proc.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := w.proc.Start(); err != nil {
+		return err
+	}
+	w.stdin = stdin
+	w.started = true
+	return nil
+}
+
+func (w *pagerWriter) writePager(p []byte) (int, error) {
+	n, err := w.stdin.Write(p)
+	if err != nil {
+		w.closed = true
+		return n, errPagerClosed
+	}
+	return n, nil
+}
+
+// Close flushes the held back output, or waits for the pager to quit.
+func (w *pagerWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if !w.started {
+		_, err := w.out.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	w.stdin.Close()
+	return w.proc.Wait()
+}
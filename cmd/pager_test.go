@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "default", want: []string{"less", "-RFX"}},
+		{name: "PAGER", env: map[string]string{"PAGER": "more -s"}, want: []string{"more", "-s"}},
+		{name: "NYAN_PAGER over PAGER", env: map[string]string{"NYAN_PAGER": "most", "PAGER": "more"}, want: []string{"most"}},
+		{name: "bare less", env: map[string]string{"PAGER": "less"}, want: []string{"less", "-RFX"}},
+		{name: "less with options", env: map[string]string{"PAGER": "less -S"}, want: []string{"less", "-S"}},
+		{name: "empty NYAN_PAGER", env: map[string]string{"NYAN_PAGER": "", "PAGER": "more"}, want: nil},
+		{name: "cat", env: map[string]string{"PAGER": "cat"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NYAN_PAGER", "PAGER"} {
+				if value, ok := tt.env[key]; ok {
+					t.Setenv(key, value)
+				} else {
+					unsetenv(t, key)
+				}
+			}
+
+			assert.Equal(t, tt.want, pagerCommand())
+		})
+	}
+}
+
+func TestNewPagerWriter(t *testing.T) {
+	t.Setenv("NYAN_PAGER", "more")
+
+	t.Run("never", func(t *testing.T) {
+		assert.Nil(t, newPagerWriter(pagingNever, &bytes.Buffer{}))
+	})
+
+	t.Run("always", func(t *testing.T) {
+		w := newPagerWriter(pagingAlways, &bytes.Buffer{})
+		require.NotNil(t, w)
+		assert.Equal(t, []string{"more"}, w.args)
+		assert.Zero(t, w.height)
+		assert.Zero(t, w.idle)
+	})
+
+	t.Run("auto without terminal", func(t *testing.T) {
+		originalIsTerminalFunc := isTerminalFunc
+		isTerminalFunc = func(fd uintptr) bool { return false }
+		t.Cleanup(func() {
+			isTerminalFunc = originalIsTerminalFunc
+		})
+
+		assert.Nil(t, newPagerWriter(pagingAuto, &bytes.Buffer{}))
+	})
+
+	t.Run("auto on terminal", func(t *testing.T) {
+		setupTerminalMock(t)
+		originalTerminalHeightFunc := terminalHeightFunc
+		terminalHeightFunc = func() int { return 24 }
+		t.Cleanup(func() {
+			terminalHeightFunc = originalTerminalHeightFunc
+		})
+
+		w := newPagerWriter(pagingAuto, &bytes.Buffer{})
+		require.NotNil(t, w)
+		assert.Equal(t, 24, w.height)
+		assert.Equal(t, pagerIdle, w.idle)
+	})
+}
+
+func TestPagerWriter(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is not available")
+	}
+	newWriter := func(o, p *bytes.Buffer, height int) *pagerWriter {
+		return &pagerWriter{out: o, stdout: p, args: []string{"sed", "s/^/> /"}, height: height}
+	}
+
+	t.Run("fits the screen", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := newWriter(&o, &p, 3)
+		w.Write([]byte("1\n2\n"))
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "1\n2\n", o.String())
+		assert.Empty(t, p.String())
+	})
+
+	t.Run("taller than the screen", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := newWriter(&o, &p, 3)
+		w.Write([]byte("1\n2\n"))
+		w.Write([]byte("3\n4\n"))
+		require.NoError(t, w.Close())
+
+		assert.Empty(t, o.String())
+		assert.Equal(t, "> 1\n> 2\n> 3\n> 4\n", p.String())
+	})
+
+	t.Run("stalled output", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := newWriter(&o, &p, 3)
+		w.idle = 10 * time.Millisecond
+		w.Write([]byte("1\n"))
+		assert.Eventually(t, func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.started
+		}, time.Second, time.Millisecond)
+		w.Write([]byte("2\n"))
+		require.NoError(t, w.Close())
+
+		assert.Empty(t, o.String())
+		assert.Equal(t, "> 1\n> 2\n", p.String())
+	})
+
+	t.Run("output finished before idle", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := newWriter(&o, &p, 3)
+		w.idle = time.Hour
+		w.Write([]byte("1\n"))
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "1\n", o.String())
+		assert.Empty(t, p.String())
+	})
+
+	t.Run("always", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := newWriter(&o, &p, 0)
+		w.Write([]byte("1\n"))
+		require.NoError(t, w.Close())
+
+		assert.Empty(t, o.String())
+		assert.Equal(t, "> 1\n", p.String())
+	})
+
+	t.Run("pager quits", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := &pagerWriter{out: &o, stdout: &p, args: []string{"head", "-1"}}
+		line := []byte(strings.Repeat("x", 1023) + "\n")
+		var err error
+		for range 10000 {
+			if _, err = w.Write(line); err != nil {
+				break
+			}
+		}
+		assert.ErrorIs(t, err, errPagerClosed)
+		_, err = w.Write(line)
+		assert.ErrorIs(t, err, errPagerClosed)
+		require.NoError(t, w.Close())
+
+		assert.Empty(t, o.String())
+		assert.Equal(t, string(line), p.String())
+	})
+
+	t.Run("pager not found", func(t *testing.T) {
+		var o, p bytes.Buffer
+		w := &pagerWriter{out: &o, stdout: &p, args: []string{"nyan-no-such-pager"}}
+		w.Write([]byte("1\n"))
+		w.Write([]byte("2\n"))
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "1\n2\n", o.String())
+		assert.Empty(t, p.String())
+	})
+}
+
+func TestValidatePagingMode(t *testing.T) {
+	for _, mode := range pagingModes {
+		assert.NoError(t, validatePagingMode(mode))
+	}
+	assert.EqualError(t, validatePagingMode("sometimes"), `invalid paging mode "sometimes" (available: [auto always never])`)
+}
+
+// unsetenv unsets the environment variable for the duration of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+// endlessReader reads lines without an end, like `tail -f`.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+		if i%80 == 79 {
+			p[i] = '\n'
+		}
+	}
+	return len(p), nil
+}
+
+func TestPagerQuit(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true is not available")
+	}
+	t.Setenv("NYAN_PAGER", "true")
+	var o, e bytes.Buffer
+
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetIn(endlessReader{})
+	rootCmd.SetArgs([]string{"--paging", "always", "-l", "text"})
+	done := make(chan error)
+	go func() { done <- rootCmd.Execute() }()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Empty(t, o.String())
+		assert.Empty(t, e.String())
+	case <-time.After(10 * time.Second):
+		t.Fatal("nyan kept reading after the pager quit")
+	}
+}
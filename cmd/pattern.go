@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// compilePatterns compiles the --highlight-pattern regular expressions, case-insensitively
+// with --ignore-case.
+func compilePatterns(patterns []string, ignoreCase bool) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("--highlight-pattern: %w", err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchCounter counts the matches of --highlight-pattern in each file, and prints the
+// counts on stderr.
+type matchCounter struct {
+	w     io.Writer
+	count int
+}
+
+// onMatch is the highlight.Options.OnMatch function.
+func (c *matchCounter) onMatch() {
+	c.count++
+}
+
+// report prints the count of matches in a file and starts counting for the next one.
+// Nothing is printed for a file which couldn't be shown.
+func (c *matchCounter) report(name string, err error) {
+	if c == nil {
+		return
+	}
+	if err == nil {
+		unit := "matches"
+		if c.count == 1 {
+			unit = "match"
+		}
+		fmt.Fprintf(c.w, "%s: %d %s\n", name, c.count, unit)
+	}
+	c.count = 0
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilePatterns(t *testing.T) {
+	t.Run("ignore case", func(t *testing.T) {
+		patterns, err := compilePatterns([]string{"fmt", "Print.*"}, true)
+
+		require.NoError(t, err)
+		assert.True(t, patterns[0].MatchString("FMT"))
+		assert.True(t, patterns[1].MatchString("println"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := compilePatterns([]string{"("}, false)
+
+		assert.EqualError(t, err, "--highlight-pattern: error parsing regexp: missing closing ): `(`")
+	})
+}
+
+func TestHighlightPatternOption(t *testing.T) {
+	const match = "\x1b[1m\x1b[38;5;16m\x1b[48;5;220mfmt\x1b[0m"
+
+	t.Run("overlay", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--highlight-pattern", "fmt", "--color=always", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "\x1b[38;5;186m\"\x1b[0m"+match+"\x1b[38;5;186m\"\x1b[0m")
+		assert.Contains(t, o.String(), match+"\x1b[38;5;231m.\x1b[0m\x1b[38;5;148mPrintln\x1b[0m")
+		assert.Equal(t, "testdata/dummy.go: 2 matches\n", e.String())
+	})
+
+	t.Run("ignore case", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--highlight-pattern", "MAIN", "--highlight-pattern", "hello", "-i", "--color=never", "testdata/dummy.go", "testdata/nyan/config"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.NotContains(t, o.String(), "\x1b")
+		assert.Equal(t, "testdata/dummy.go: 3 matches\ntestdata/nyan/config: 0 matches\n", e.String())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetIn(strings.NewReader("package main\n"))
+		rootCmd.SetArgs([]string{"--highlight-pattern", "main", "--color=never"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "package main\n", o.String())
+		assert.Equal(t, "STDIN: 1 match\n", e.String())
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--highlight-pattern", "(", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: --highlight-pattern: error parsing regexp: missing closing ): `(`\n", e.String())
+	})
+}
@@ -1,98 +1,343 @@
 package cmd
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/alecthomas/chroma/v2"
-	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/mattn/go-colorable"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/toshimaru/nyan/highlight"
 	"github.com/toshimaru/nyan/styles"
+	"golang.org/x/text/transform"
 )
 
 var (
 	isTerminalFunc = isatty.IsTerminal
 	version        = "dev"
-
-	listThemes  bool
-	showVersion bool
-	theme       string
-	language    string
-	number      bool
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "nyan [flags] [FILE]...",
-	Short: "Colorizing cat command.",
-	Long:  "Colorizing `cat` command with syntax highlighting.",
-	Example: `$ nyan FILE
+// options holds the flag values of a single command invocation.
+type options struct {
+	listThemes    bool
+	listLangs     bool
+	showVersion   bool
+	theme         string
+	language      string
+	number        bool
+	colorMode     string
+	colorDepth    string
+	paging        string
+	themeFiles    []string
+	mapSyntax     []string
+	debugDetect   bool
+	binary        string
+	encoding      string
+	recursive     bool
+	include       []string
+	exclude       []string
+	style         string
+	hyperlink     string
+	lineRange     []string
+	highlightLine []string
+	pattern       []string
+	ignoreCase    bool
+	configFile    string
+
+	// sources are the origins of the flag values set by the config file or NYAN_OPTS, by flag name.
+	sources map[string]string
+	// decorations are the components parsed from --style.
+	decorations decorations
+	// decorator draws the decorations around files. It's nil without decorations.
+	decorator *decorator
+	// hyperlinker makes the links of --hyperlink. It's nil without links.
+	hyperlinker *hyperlinker
+	// inDir is set while printing the files of a directory argument.
+	inDir bool
+	// detectLog receives the stages of the language detection with --debug-detect.
+	detectLog io.Writer
+	// themes are the themes loaded from --theme-file, by name.
+	themes map[string]*chroma.Style
+	// lineRanges are the ranges parsed from --line-range.
+	lineRanges []highlight.LineRange
+	// highlightLines are the ranges parsed from --highlight-line.
+	highlightLines []highlight.LineRange
+	// patterns are the regular expressions compiled from --highlight-pattern.
+	patterns []*regexp.Regexp
+	// matches counts the matches of the patterns. It's nil without patterns.
+	matches *matchCounter
+	// syntaxMappings are the mappings parsed from --map-syntax.
+	syntaxMappings []syntaxMapping
+	// nyanrcs are the mappings of the nearest .nyanrc, by directory.
+	nyanrcs map[string][]syntaxMapping
+	// attributeFiles are the parsed .gitattributes files, by path.
+	attributeFiles map[string]*attributeFile
+}
+
+// NewRootCmd returns a new nyan command.
+// Each command holds its own flag values, so that commands can be run concurrently.
+func NewRootCmd() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "nyan [flags] [FILE]...",
+		Short: "Colorizing cat command.",
+		Long:  "Colorizing `cat` command with syntax highlighting.",
+		Example: `$ nyan FILE
 $ nyan FILE1 FILE2 FILE3
 $ nyan -t solarized-dark FILE
 $ nyan -l go FILE`,
-	RunE:              cmdMain,
-	SilenceErrors:     true,
-	SilenceUsage:      false,
-	CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		Args:              cobra.ArbitraryArgs,
+		PersistentPreRunE: o.applyConfig,
+		RunE:              o.run,
+		SilenceErrors:     true,
+		SilenceUsage:      false,
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+	}
+
+	o.addFlags(cmd.PersistentFlags())
+	cmd.PersistentFlags().StringVar(&o.configFile, "config", "", "Read default flags from the file (default: $XDG_CONFIG_HOME/nyan/config or ~/.config/nyan/config)")
+
+	for _, sub := range []*cobra.Command{newThemeCmd(), newLanguagesCmd(), newConfigCmd(o)} {
+		cmd.AddCommand(o.fileFirst(sub))
+	}
+
+	// The user themes are loaded by the command which runs, so the help lists them itself.
+	help := cmd.HelpFunc()
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		o.addUserThemes(c)
+		cmd.PersistentFlags().Lookup("theme").Usage = themeUsage(o.themeNames())
+		help(c, args)
+	})
+
+	cmd.SetOut(colorable.NewColorableStdout())
+	cmd.SetErr(colorable.NewColorableStderr())
+	return cmd
 }
 
-func init() {
-	rootCmd.PersistentFlags().BoolVarP(&listThemes, "list-themes", "T", false, `List available color themes`)
-	rootCmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, `Show version`)
-	rootCmd.PersistentFlags().StringVarP(&theme, "theme", "t", "monokai", fmt.Sprintf("Set color theme for syntax highlighting\nAvailable themes: %s", styles.Names()))
-	rootCmd.PersistentFlags().StringVarP(&language, "language", "l", "", "Specify language for syntax highlighting")
-	rootCmd.PersistentFlags().BoolVarP(&number, "number", "n", false, "Output with line numbers")
+// fileFirst makes a subcommand show the file of the same name in the current directory
+// instead, if there is one, so that `nyan config` still works as cat for ./config.
+func (o *options) fileFirst(sub *cobra.Command) *cobra.Command {
+	validate, runE := sub.Args, sub.RunE
+	sub.Args = func(cmd *cobra.Command, args []string) error {
+		if isFile(cmd.Name()) || validate == nil {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+	sub.RunE = func(cmd *cobra.Command, args []string) error {
+		if isFile(cmd.Name()) {
+			return o.run(cmd, append([]string{cmd.Name()}, args...))
+		}
+		if runE == nil {
+			return cmd.Help()
+		}
+		return runE(cmd, args)
+	}
+	return sub
+}
 
-	rootCmd.SetOut(colorable.NewColorableStdout())
-	rootCmd.SetErr(colorable.NewColorableStderr())
+// addFlags adds the flags which can also be set by the config file and NYAN_OPTS.
+func (o *options) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVarP(&o.listThemes, "list-themes", "T", false, `List available color themes`)
+	fs.BoolVarP(&o.listLangs, "list-languages", "L", false, "List available languages, filtered by the argument if given")
+	fs.BoolVarP(&o.showVersion, "version", "v", false, `Show version`)
+	fs.StringVarP(&o.theme, "theme", "t", "monokai", themeUsage(styles.Names()))
+	fs.StringArrayVar(&o.themeFiles, "theme-file", nil, "Load a color theme from a file (.xml, .json, .yaml)\nThe loaded theme is used unless --theme is given")
+	fs.StringVarP(&o.language, "language", "l", "", "Specify language for syntax highlighting")
+	fs.StringArrayVar(&o.mapSyntax, "map-syntax", nil, "Map file names matching a glob to a language (GLOB:LANG, e.g. '*.tmpl:html')\nMappings are also read from .nyanrc in the directory of the file or its parents")
+	fs.BoolVar(&o.debugDetect, "debug-detect", false, "Explain how the language of each file is chosen, on stderr")
+	fs.BoolVarP(&o.recursive, "recursive", "r", false, "Show the files in directory arguments recursively, with a header for each file\nFiles ignored by .gitignore and binary files are skipped")
+	fs.StringArrayVar(&o.include, "include", nil, "Show only the files matching a glob with --recursive (e.g. '*.go')")
+	fs.StringArrayVar(&o.exclude, "exclude", nil, "Skip the files and directories matching a glob with --recursive (e.g. 'vendor/')")
+	fs.StringVar(&o.style, "style", styleAuto, fmt.Sprintf("Decorations drawn around each file, separated by commas %v\nIn auto mode, headers are drawn for the files of directories", styleComponents))
+	fs.StringVar(&o.hyperlink, "hyperlink", "", fmt.Sprintf("Link file headers and line numbers to the files with OSC 8 hyperlinks, when colors are used\nThe URL template has {path}, {line} and {host} placeholders (default %q)", defaultHyperlink))
+	fs.Lookup("hyperlink").NoOptDefVal = defaultHyperlink
+	fs.StringArrayVar(&o.lineRange, "line-range", nil, "Show only the lines in a range (START:END, START: or :END, e.g. 30:60)\nThe flag can be repeated")
+	fs.StringArrayVarP(&o.highlightLine, "highlight-line", "H", nil, "Highlight a line or a range of lines (N or START:END) with the background of the theme\nThe flag can be repeated")
+	fs.StringArrayVar(&o.pattern, "highlight-pattern", nil, "Highlight the matches of a regular expression, and print the number of matches in each file on stderr\nThe flag can be repeated")
+	fs.BoolVarP(&o.ignoreCase, "ignore-case", "i", false, "Match --highlight-pattern case-insensitively")
+	fs.BoolVarP(&o.number, "number", "n", false, "Output with line numbers")
+	fs.StringVar(&o.colorDepth, "color-depth", depthAuto, fmt.Sprintf("Set color depth of the terminal %v\nIn auto mode, it is detected from COLORTERM and TERM", colorDepths))
+	fs.StringVar(&o.colorMode, "color", colorAuto, fmt.Sprintf("When to use colors %v\nNO_COLOR, FORCE_COLOR and CLICOLOR_FORCE are honored in auto mode", colorModes))
+	fs.StringVar(&o.binary, "binary", binaryAuto, fmt.Sprintf("How to show binary files %v\nIn auto mode, they are skipped on a terminal, and written as is otherwise", binaryModes))
+	fs.StringVar(&o.encoding, "encoding", encodingAuto, fmt.Sprintf("Set the character encoding of the input, e.g. %v\nIn auto mode, it is detected from the BOM and the content", encodingNames[1:]))
+	fs.StringVar(&o.paging, "paging", pagingAuto, fmt.Sprintf("When to use a pager %v\nThe pager is taken from $NYAN_PAGER or $PAGER (default: %q)", pagingModes, defaultPager))
 }
 
 // Execute root commands. This is called by `main.main()`.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	if err := NewRootCmd().Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
-func cmdMain(cmd *cobra.Command, args []string) (err error) {
-	if checkSpecialFlags(cmd) {
-		return
+func (o *options) run(cmd *cobra.Command, args []string) (err error) {
+	cmd.SilenceUsage = true
+
+	o.themes = map[string]*chroma.Style{}
+	o.addUserThemes(cmd)
+	for _, path := range o.themeFiles {
+		style, err := styles.LoadFile(path)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+		o.themes[style.Name] = style
+		if !cmd.Flags().Changed("theme") {
+			o.theme = style.Name
+		}
 	}
 
-	var data []byte
-	var lexer chroma.Lexer
+	if special, err := o.checkSpecialFlags(cmd, args); special {
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+		}
+		return err
+	}
 
-	if language != "" {
-		lexer = lexers.Get(language)
+	if err = validateColorMode(o.colorMode); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err = validateColorDepth(o.colorDepth); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err = validatePagingMode(o.paging); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err = validateBinaryMode(o.binary); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.decorations, err = parseStyle(o.style, o.recursive); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.hyperlink != "" {
+		if o.hyperlinker, err = newHyperlinker(o.hyperlink); err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+	}
+	if err = validateEncoding(o.encoding); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if err = o.validateTheme(o.theme); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.language != "" {
+		if err = validateLanguage(o.language); err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+	}
+	if o.lineRanges, err = parseLineRanges(o.lineRange); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.highlightLines, err = parseLineRanges(o.highlightLine); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.patterns, err = compilePatterns(o.pattern, o.ignoreCase); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.syntaxMappings, err = parseSyntaxMappings(o.mapSyntax); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
 	}
 
-	cmd.SilenceUsage = true
+	if pager := newPagerWriter(o.paging, cmd.OutOrStdout()); pager != nil {
+		cmd.SetOut(pager)
+		defer func() {
+			cmd.SetOut(pager.out)
+			if closeErr := pager.Close(); closeErr != nil && err == nil {
+				cmd.PrintErrln("Error:", closeErr)
+				err = closeErr
+			}
+		}()
+	}
 
+	if o.debugDetect {
+		o.detectLog = cmd.ErrOrStderr()
+	}
+	opts := o.highlightOptions()
+	opts.OnDetect = o.detectionTracer()
+	if len(o.patterns) > 0 {
+		o.matches = &matchCounter{w: cmd.ErrOrStderr()}
+		opts.OnMatch = o.matches.onMatch
+	}
+	if opts.Plain {
+		o.hyperlinker = nil
+	}
+	out := cmd.OutOrStdout()
+	if o.decorations.any() {
+		o.decorator = newDecorator(out, o.decorations, opts, o.hyperlinker)
+		out = o.decorator
+	}
 	if len(args) < 1 || args[0] == "-" {
-		if data, err = io.ReadAll(cmd.InOrStdin()); err != nil {
+		o.startDetectTrace("(stdin)")
+		o.decorator.begin("STDIN", -1)
+		err = o.printInput(out, cmd.InOrStdin(), "", opts)
+		if endErr := o.decorator.end(err); err == nil {
+			err = endErr
+		}
+		if errors.Is(err, errPagerClosed) {
+			return nil
+		}
+		o.matches.report("STDIN", err)
+		if errors.Is(err, errBinarySkipped) {
+			warnBinarySkipped(cmd, "stdin")
+			err = nil
+		}
+		if err != nil {
 			cmd.PrintErrln("Error:", err)
 			return err
 		}
-		if lexer == nil {
-			lexer = lexers.Analyse(string(data))
-		}
-		printData(&data, cmd, lexer)
 	} else {
 		var lastErr error
 		for _, filename := range args {
-			if data, err = os.ReadFile(filename); err != nil {
-				cmd.PrintErrln("Error:", err)
-				lastErr = err
+			if isDir(filename) {
+				err = o.printDir(cmd, out, filename, opts)
+				if errors.Is(err, errPagerClosed) {
+					return nil
+				}
+				if err != nil {
+					lastErr = err
+				}
+				continue
+			}
+			o.startDetectTrace(filename)
+			o.decorator.begin(filename, fileSize(filename))
+			err = o.printArg(out, filename, opts)
+			if endErr := o.decorator.end(err); err == nil {
+				err = endErr
+			}
+			if errors.Is(err, errPagerClosed) {
+				return nil
+			}
+			o.matches.report(filename, err)
+			if errors.Is(err, errBinarySkipped) {
+				warnBinarySkipped(cmd, filename)
 				continue
 			}
-			if language == "" {
-				lexer = lexers.Match(filename)
+			if err != nil {
+				cmd.PrintErrln("Error:", err)
+				lastErr = err
 			}
-			printData(&data, cmd, lexer)
 		}
 		if lastErr != nil {
 			return lastErr
@@ -101,122 +346,174 @@ func cmdMain(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func printData(data *[]byte, cmd *cobra.Command, lexer chroma.Lexer) {
-	out := cmd.OutOrStdout()
-	if number {
-		w := &numberWriter{
-			w:           out,
-			currentLine: 1,
-		}
-		out = w
-		defer w.Flush()
+// lookupStyle returns the named style from the user and --theme-file themes or the styles
+// registry.
+func (o *options) lookupStyle(name string) (*chroma.Style, bool) {
+	if style, ok := o.themes[name]; ok {
+		return style, true
 	}
+	return styles.Lookup(name)
+}
 
-	if isTerminalFunc(os.Stdout.Fd()) {
-		if lexer == nil {
-			lexer = lexers.Fallback
+// themeNames returns the names of the user and --theme-file themes and the registered styles.
+func (o *options) themeNames() []string {
+	names := styles.Names()
+	for name := range o.themes {
+		if _, ok := styles.Lookup(name); !ok {
+			names = append(names, name)
 		}
-		iterator, _ := lexer.Tokenise(nil, string(*data))
-		formatter := formatters.Get("terminal256")
-		formatter.Format(out, styles.Get(theme), iterator)
-	} else {
-		fmt.Fprint(out, string(*data))
 	}
+	sort.Strings(names)
+	return names
 }
 
-func checkSpecialFlags(cmd *cobra.Command) bool {
-	if showVersion {
-		cmd.Println("version", version)
-		return true
-	} else if listThemes {
-		printThemes(cmd)
-		return true
+// highlightOptions returns the highlight options set by the flags.
+func (o *options) highlightOptions() highlight.Options {
+	style, _ := o.lookupStyle(o.theme)
+	return highlight.Options{
+		Theme:          o.theme,
+		Style:          style,
+		Language:       o.language,
+		Formatter:      terminalFormatter(o.colorDepth),
+		Plain:          !useColor(o.colorMode),
+		Number:         o.number,
+		LineRanges:     o.lineRanges,
+		HighlightLines: o.highlightLines,
+		Patterns:       o.patterns,
 	}
-	return false
 }
 
-const sampleCode = `
-  // Sample Code in Go
-  package main
-
-  import "fmt"
-
-  func main() {
-  	fmt.Println("Hello nyan cat command 😺")
-  }
-`
-
-func printThemes(cmd *cobra.Command) {
-	for _, theme = range styles.Names() {
-		cmd.Println("Theme:", theme)
-		code := []byte(sampleCode)
-		lexer := lexers.Get("go")
-		printData(&code, cmd, lexer)
-		cmd.Println()
+// printArg highlights a FILE argument, which is either a file or a member of an archive.
+// An archive without a member name lists the members.
+func (o *options) printArg(w io.Writer, arg string, opts highlight.Options) error {
+	archive, member, ok := splitArchiveArg(arg)
+	if !ok {
+		return o.printFile(w, arg, opts)
 	}
-}
 
-type numberWriter struct {
-	w           io.Writer
-	currentLine uint64
-	buf         []byte
+	var err error
+	if cleanMemberName(member) == "" {
+		err = listArchive(w, archive)
+	} else {
+		err = o.printArchiveMember(w, archive, member, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", archive, err)
+	}
+	return nil
 }
 
-func (w *numberWriter) Write(p []byte) (n int, err error) {
-	// Early return.
-	// Can't calculate the line numbers until the line breaks are made, so store them all in a buffer.
-	if !bytes.Contains(p, []byte{'\n'}) {
-		w.buf = append(w.buf, p...)
-		return len(p), nil
+// printFile highlights a file.
+func (o *options) printFile(w io.Writer, filename string, opts highlight.Options) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
 
-	var (
-		original = p
-		tokenLen uint
-	)
-	for i, c := range original {
-		tokenLen++
-		if c != '\n' {
-			continue
-		}
+	opts.LineLink = o.hyperlinker.lineLink(filename)
+	if err := o.printInput(w, f, filename, opts); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
 
-		token := p[:tokenLen]
-		p = original[i+1:]
-		tokenLen = 0
+// printInput highlights r, decompressing it when it's compressed and decoding it to UTF-8
+// by --encoding, and prints binary content according to --binary. The language is chosen
+// by the flags, or by the syntax mappings for name when they tell it. The name is empty
+// for stdin.
+func (o *options) printInput(w io.Writer, r io.Reader, name string, opts highlight.Options) error {
+	// The file is hidden behind the decompressing and decoding readers from here on.
+	if f, ok := r.(*os.File); ok {
+		opts.RegularFile = isRegularFile(f)
+	}
+	in, err := openInput(r, name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	o.traceCompression(in)
 
-		format := "%6d\t%s%s"
-		if w.currentLine > 999999 {
-			format = "%d\t%s%s"
+	head, r, err := peek(in.Reader, highlight.BinarySniffSize)
+	if err != nil {
+		return err
+	}
+	enc := o.inputEncoding(head)
+	if enc.utf8 && highlight.IsBinary(head) {
+		return o.printBinary(w, r, opts)
+	}
+	if d := o.decorator; d != nil {
+		d.file.encoding = enc.name
+		onDetect := opts.OnDetect
+		opts.OnDetect = func(detection highlight.Detection) {
+			switch detection.Lexer {
+			case nil:
+			case lexers.Fallback:
+				d.file.language = plainTextLanguage
+			default:
+				d.file.language = detection.Lexer.Config().Name
+			}
+			if onDetect != nil {
+				onDetect(detection)
+			}
 		}
+	}
+	in.Reader = r
+	if enc.decoder != nil {
+		in.Reader = transform.NewReader(r, enc.decoder.NewDecoder())
+	}
 
-		_, er := fmt.Fprintf(w.w, format, w.currentLine, string(w.buf), string(token))
-		if er != nil {
-			return i + 1, er
+	opts.Filename = in.name
+	if o.language == "" {
+		o.traceDetect("--language", "not given")
+		if in.name != "" {
+			if opts.Language, err = o.mappedLanguage(in.name); err != nil {
+				return err
+			}
 		}
-		w.buf = w.buf[:0]
-		w.currentLine++
 	}
+	return highlight.Highlight(w, in, opts)
+}
 
-	if len(p) > 0 {
-		w.buf = append(w.buf, p...)
+func (o *options) checkSpecialFlags(cmd *cobra.Command, args []string) (bool, error) {
+	if o.showVersion {
+		cmd.Println("version", version)
+		return true, nil
+	} else if o.listThemes {
+		o.printThemes(cmd)
+		return true, nil
+	} else if o.listLangs {
+		// The argument is a filter, as with the languages command.
+		if len(args) > 1 {
+			return true, fmt.Errorf("--list-languages accepts at most one filter, received %d", len(args))
+		}
+		filter := ""
+		if len(args) > 0 {
+			filter = args[0]
+		}
+		return true, printLanguages(cmd.OutOrStdout(), filter)
 	}
-	return len(original), nil
+	return false, nil
 }
 
-func (w *numberWriter) Flush() error {
-	terminalReset := []byte("\u001B[0m")
-	if bytes.Equal(w.buf, terminalReset) {
-		// In almost all cases, a control code is passed last to reset the terminal's color code.
-		// This is not a printable character and should not be counted as a line, so it is output as is without a line number.
-		_, err := fmt.Fprintf(w.w, "%s", string(w.buf))
-		return err
-	}
+const sampleCode = `
+  // Sample Code in Go
+  package main
 
-	format := "%6d\t%s"
-	if w.currentLine > 999999 {
-		format = "%d\t%s"
+  import "fmt"
+
+  func main() {
+  	fmt.Println("Hello nyan cat command 😺")
+  }
+`
+
+func (o *options) printThemes(cmd *cobra.Command) {
+	opts := o.highlightOptions()
+	opts.Lexer = lexers.Get("go")
+	for _, name := range o.themeNames() {
+		cmd.Println("Theme:", name)
+		opts.Style, _ = o.lookupStyle(name)
+		highlight.Highlight(cmd.OutOrStdout(), strings.NewReader(sampleCode), opts)
+		cmd.Println()
 	}
-	_, err := fmt.Fprintf(w.w, format, w.currentLine, string(w.buf))
-	w.buf = w.buf[:0]
-	return err
 }
@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toshimaru/nyan/styles"
 )
 
 const (
@@ -16,23 +24,57 @@ const (
 )
 
 func TestExecute(t *testing.T) {
-	t.Cleanup(resetFlags)
-	rootCmd.SetArgs([]string{"--help"})
+	originalArgs := os.Args
+	os.Args = []string{"nyan", "--help"}
+	t.Cleanup(func() {
+		os.Args = originalArgs
+	})
 	Execute()
 }
 
 func TestCommandExecute(t *testing.T) {
+	rootCmd := NewRootCmd()
+	rootCmd.SetArgs([]string{})
+	rootCmd.SetIn(strings.NewReader(""))
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
 }
 
+func TestConcurrentExecute(t *testing.T) {
+	setupTerminalMock(t)
+	args := [][]string{
+		{"--theme", "vim", "testdata/dummy.go"},
+		{"--theme", "monokai", "--number", "testdata/dummy.go"},
+		{"--color", "never", "testdata/dummyfile"},
+	}
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, len(args))
+	for i := range args {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&outputs[i])
+			rootCmd.SetErr(&outputs[i])
+			rootCmd.SetArgs(args[i])
+			rootCmd.Execute()
+		}()
+	}
+	wg.Wait()
+
+	assert.Contains(t, outputs[0].String(), "\x1b[38;5;164mpackage\x1b[0m")
+	assert.True(t, strings.HasPrefix(outputs[1].String(), "     1\t\x1b[38;5;197mpackage\x1b[0m"))
+	assert.Equal(t, "This is dummy.", outputs[2].String())
+}
+
 func TestHelpCommand(t *testing.T) {
-	t.Cleanup(resetFlags)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"--help"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--help"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -44,9 +86,10 @@ func TestHelpCommand(t *testing.T) {
 
 func TestInvalidFilename(t *testing.T) {
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"InvalidFilename"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"InvalidFilename"})
 	err := rootCmd.Execute()
 
 	assert.Error(t, err)
@@ -57,9 +100,10 @@ func TestInvalidFilename(t *testing.T) {
 func TestCmdExecute(t *testing.T) {
 	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"testdata/dummy.go"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"testdata/dummy.go"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -71,23 +115,25 @@ func TestCmdExecute(t *testing.T) {
 func TestUnknownExtension(t *testing.T) {
 	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"testdata/dummy.go.unknown"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"testdata/dummy.go.unknown"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
 	assert.Empty(t, e.String())
-	assert.NotEmpty(t, o.String())
-	assert.Contains(t, o.String(), _unhighlightedGoCode())
+	// The language is detected from the content.
+	assert.Contains(t, o.String(), highlightedGoCode)
 }
 
 func TestLanguageOption(t *testing.T) {
-	setupTerminalMockWithStrings(t)
+	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"--language", "go", "testdata/dummy.go.unknown"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--language", "go", "testdata/dummy.go.unknown"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -96,41 +142,56 @@ func TestLanguageOption(t *testing.T) {
 	assert.Contains(t, o.String(), highlightedGoCode)
 }
 
-func TestInvalidLanguageOption(t *testing.T) {
-	setupTerminalMockWithStrings(t)
+func TestTextLanguageOption(t *testing.T) {
+	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"--language", "invalid_lang", "testdata/dummy.go"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--language", "text", "testdata/dummy.go"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
 	assert.Empty(t, e.String())
-	assert.NotEmpty(t, o.String())
 	assert.Contains(t, o.String(), _unhighlightedGoCode())
 }
 
+func TestInvalidLanguageOption(t *testing.T) {
+	setupTerminalMock(t)
+	var o, e bytes.Buffer
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--language", "goo", "testdata/dummy.go"})
+	err := rootCmd.Execute()
+
+	assert.Error(t, err)
+	assert.Empty(t, o.String())
+	assert.Contains(t, e.String(), `Error: unknown language "goo", did you mean one of "go", `)
+}
+
 func TestMultipleFiles(t *testing.T) {
 	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"testdata/dummy.go", "testdata/dummy.go.unknown"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"testdata/dummy.go", "testdata/dummy.go.unknown"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
 	assert.Empty(t, e.String())
 	assert.NotEmpty(t, o.String())
-	assert.Contains(t, o.String(), highlightedGoCode)
-	assert.Contains(t, o.String(), _unhighlightedGoCode())
+	assert.Equal(t, 2, strings.Count(o.String(), highlightedGoCode))
 }
 
 func TestMultipleFilesWithInvalidFileError(t *testing.T) {
 	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"testdata/dummy.go", "InvalidFilename", "testdata/dummyfile"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"testdata/dummy.go", "InvalidFilename", "testdata/dummyfile"})
 	err := rootCmd.Execute()
 
 	assert.Error(t, err)
@@ -142,9 +203,10 @@ func TestMultipleFilesWithInvalidFileError(t *testing.T) {
 
 func TestCompletionDisabled(t *testing.T) {
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"completion"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"completion"})
 	err := rootCmd.Execute()
 
 	assert.Error(t, err)
@@ -155,11 +217,11 @@ func TestCompletionDisabled(t *testing.T) {
 func TestThemes(t *testing.T) {
 	setupTerminalMock(t)
 	var o, e bytes.Buffer
-	rootCmd.SetOut(&o)
-	rootCmd.SetErr(&e)
 
 	t.Run("Valid Theme", func(t *testing.T) {
-		t.Cleanup(resetStrings)
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
 		rootCmd.SetArgs([]string{"testdata/dummy.go", "--theme", "vim"})
 		err := rootCmd.Execute()
 
@@ -170,25 +232,83 @@ func TestThemes(t *testing.T) {
 	})
 
 	t.Run("Invalid Theme", func(t *testing.T) {
-		t.Cleanup(resetStrings)
 		o.Reset()
-		rootCmd.SetArgs([]string{"testdata/dummy.go", "--theme", "invalid"})
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"testdata/dummy.go", "--theme", "dracla"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Empty(t, o.String())
+		assert.Contains(t, e.String(), `Error: unknown theme "dracla", did you mean "dracula"?`)
+	})
+}
+
+func TestThemeFileOption(t *testing.T) {
+	setupTerminalMock(t)
+	var o, e bytes.Buffer
+
+	t.Run("Valid Theme File", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--theme-file", "testdata/theme.json", "testdata/dummy.go"})
 		err := rootCmd.Execute()
 
 		assert.NoError(t, err)
 		assert.Empty(t, e.String())
-		assert.NotEmpty(t, o.String())
-		assert.Contains(t, o.String(), "[1m[38;5;231mpackage")
+		assert.Contains(t, o.String(), "\x1b[38;5;196mpackage\x1b[0m")
+	})
+
+	t.Run("With Theme Option", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--theme-file", "testdata/theme.json", "--theme", "monokai", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Contains(t, o.String(), highlightedGoCode)
+	})
+
+	t.Run("Listed in Themes", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--theme-file", "testdata/theme.json", "--list-themes"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "Theme: testdata-theme\n")
+		assert.NotContains(t, styles.Names(), "testdata-theme")
+	})
+
+	t.Run("Invalid Theme File", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--theme-file", "testdata/dummyfile", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), `Error: testdata/dummyfile: unsupported style file extension ""`)
+		assert.Empty(t, o.String())
 	})
 }
 
 func TestSpecialFlags(t *testing.T) {
 	var o, e bytes.Buffer
-	rootCmd.SetOut(&o)
-	rootCmd.SetErr(&e)
 
 	t.Run("version Flag", func(t *testing.T) {
-		t.Cleanup(resetFlags)
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
 		rootCmd.SetArgs([]string{"--version"})
 		err := rootCmd.Execute()
 
@@ -200,8 +320,10 @@ func TestSpecialFlags(t *testing.T) {
 	})
 
 	t.Run("listThemes Flag", func(t *testing.T) {
-		t.Cleanup(resetFlags)
 		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
 		rootCmd.SetArgs([]string{"--list-themes"})
 		err := rootCmd.Execute()
 
@@ -214,8 +336,10 @@ func TestSpecialFlags(t *testing.T) {
 	})
 
 	t.Run("multiple flags", func(t *testing.T) {
-		t.Cleanup(resetFlags)
 		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
 		rootCmd.SetArgs([]string{"--version", "--list-themes"})
 		err := rootCmd.Execute()
 
@@ -227,11 +351,85 @@ func TestSpecialFlags(t *testing.T) {
 	})
 }
 
+func TestColorOption(t *testing.T) {
+	var o, e bytes.Buffer
+
+	t.Run("always", func(t *testing.T) {
+		setupColorDepthEnv(t)
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color", "always", "--theme", "monokai", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Contains(t, o.String(), highlightedGoCode)
+	})
+
+	t.Run("never", func(t *testing.T) {
+		setupTerminalMock(t)
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color", "never", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Contains(t, o.String(), "package main")
+		assert.NotContains(t, o.String(), highlightedGoCode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color", "invalid", "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), `Error: invalid color mode "invalid"`)
+	})
+}
+
+func TestPagingOption(t *testing.T) {
+	var o, e bytes.Buffer
+
+	t.Run("never", func(t *testing.T) {
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--paging", "never", "testdata/dummyfile"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Equal(t, "This is dummy.", o.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		o.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--paging", "invalid", "testdata/dummyfile"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), `Error: invalid paging mode "invalid"`)
+	})
+}
+
 func TestUnknownFile(t *testing.T) {
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"testdata/dummyfile"})
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"testdata/dummyfile"})
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -244,10 +442,11 @@ func TestFromStdIn(t *testing.T) {
 	setupTerminalMock(t)
 	i := bytes.NewBufferString("package main")
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"--theme", "monokai"})
-	rootCmd.SetIn(i)
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--theme", "monokai"})
+	rootCmd.SetIn(i)
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -257,13 +456,14 @@ func TestFromStdIn(t *testing.T) {
 }
 
 func TestFromStdInWithLanguageOption(t *testing.T) {
-	setupTerminalMockWithStrings(t)
+	setupTerminalMock(t)
 	i := bytes.NewBufferString("package main")
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"--theme", "monokai", "--language", "go"})
-	rootCmd.SetIn(i)
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--theme", "monokai", "--language", "go"})
+	rootCmd.SetIn(i)
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -275,10 +475,11 @@ func TestFromStdInWithLanguageOption(t *testing.T) {
 func TestFromStdInWithDash(t *testing.T) {
 	i := bytes.NewBufferString("TestFromStdIn")
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"-"})
-	rootCmd.SetIn(i)
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"-"})
+	rootCmd.SetIn(i)
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -287,12 +488,77 @@ func TestFromStdInWithDash(t *testing.T) {
 	assert.Contains(t, o.String(), "TestFromStdIn")
 }
 
+func TestFromStdInStreaming(t *testing.T) {
+	in, stdin := io.Pipe()
+	stdout, out := io.Pipe()
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(out)
+	rootCmd.SetIn(in)
+	rootCmd.SetArgs([]string{"--color=always", "-l", "text"})
+	done := make(chan error)
+	go func() {
+		done <- rootCmd.Execute()
+		out.Close()
+	}()
+
+	// The first line is shown while the producer is still running.
+	fmt.Fprintln(stdin, "hi")
+	line := make(chan string)
+	go func() {
+		s, _ := bufio.NewReader(stdout).ReadString('\n')
+		line <- s
+	}()
+	select {
+	case s := <-line:
+		assert.Contains(t, s, "hi")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first line was held back")
+	}
+
+	stdin.Close()
+	go io.Copy(io.Discard, stdout)
+	assert.NoError(t, <-done)
+}
+
+func TestSubcommandFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config", "languages", "theme"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name+" file\n"), 0o644))
+	}
+	t.Chdir(dir)
+
+	for _, name := range []string{"config", "languages", "theme"} {
+		t.Run(name, func(t *testing.T) {
+			var o bytes.Buffer
+			rootCmd := NewRootCmd()
+			rootCmd.SetOut(&o)
+			rootCmd.SetArgs([]string{name, "--color=never"})
+			err := rootCmd.Execute()
+
+			assert.NoError(t, err)
+			assert.Equal(t, name+" file\n", o.String())
+		})
+	}
+
+	t.Run("with files", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"config", "--color=never", "theme"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "config file\ntheme file\n", o.String())
+	})
+}
+
 func TestNumberOption(t *testing.T) {
 	var o, e bytes.Buffer
-	rootCmd.SetArgs([]string{"-n", "testdata/dummy.go"})
-	rootCmd.SetIn(nil)
+	rootCmd := NewRootCmd()
 	rootCmd.SetOut(&o)
 	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"-n", "testdata/dummy.go"})
+	rootCmd.SetIn(nil)
 	err := rootCmd.Execute()
 
 	assert.NoError(t, err)
@@ -316,28 +582,22 @@ func TestNumberOption(t *testing.T) {
 func setupTerminalMock(t *testing.T) {
 	t.Helper()
 	originalIsTerminalFunc := isTerminalFunc
+	originalTerminalHeightFunc := terminalHeightFunc
 	isTerminalFunc = func(fd uintptr) bool { return true }
+	terminalHeightFunc = func() int { return 0 }
+	setupColorDepthEnv(t)
 	t.Cleanup(func() {
 		isTerminalFunc = originalIsTerminalFunc
+		terminalHeightFunc = originalTerminalHeightFunc
 	})
 }
 
-func setupTerminalMockWithStrings(t *testing.T) {
+// setupColorDepthEnv makes the auto-detected color depth 256 colors regardless of the
+// terminal running the tests.
+func setupColorDepthEnv(t *testing.T) {
 	t.Helper()
-	setupTerminalMock(t)
-	t.Cleanup(resetStrings)
-}
-
-func resetFlags() {
-	showVersion = false
-	listThemes = false
-	number = false
-	rootCmd.Flags().Set("help", "false")
-}
-
-func resetStrings() {
-	language = ""
-	theme = "monokai"
+	t.Setenv("COLORTERM", "")
+	t.Setenv("TERM", "xterm-256color")
 }
 
 func invalidFileErrorMsg() string {
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// maxSuggestions is the maximum number of suggestions shown for an unknown value.
+const maxSuggestions = 3
+
+func (o *options) validateTheme(name string) error {
+	if _, ok := o.lookupStyle(name); ok {
+		return nil
+	}
+	return unknownValueError("theme", name, o.themeNames(), "--list-themes")
+}
+
+func validateLanguage(name string) error {
+	if lexers.Get(name) != nil {
+		return nil
+	}
+	return unknownValueError("language", name, lexers.Names(true), "")
+}
+
+// unknownValueError builds an error for an unknown flag value, with suggestions
+// of similar candidates when there are any.
+func unknownValueError(kind, value string, candidates []string, listFlag string) error {
+	suggestions := suggest(value, candidates)
+	switch {
+	case len(suggestions) == 1:
+		return fmt.Errorf("unknown %s %q, did you mean %q?", kind, value, suggestions[0])
+	case len(suggestions) > 1:
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		return fmt.Errorf("unknown %s %q, did you mean one of %s?", kind, value, strings.Join(quoted, ", "))
+	case listFlag != "":
+		return fmt.Errorf("unknown %s %q (see `nyan %s`)", kind, value, listFlag)
+	}
+	return fmt.Errorf("unknown %s %q", kind, value)
+}
+
+// suggest returns the candidates similar to value, most similar first.
+// A candidate is similar when it's within a small edit distance of value, or starts with it.
+func suggest(value string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	value = strings.ToLower(value)
+	maxDistance := max(len(value)/3, 2)
+	// Candidates differing only in case are suggested once, preferring the lower case one.
+	seen := map[string]int{}
+	var matches []scored
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		if i, ok := seen[lower]; ok {
+			if candidate == lower {
+				matches[i].name = candidate
+			}
+			continue
+		}
+
+		distance := levenshtein(value, lower)
+		if distance <= maxDistance || (len(value) > 1 && strings.HasPrefix(lower, value)) {
+			seen[lower] = len(matches)
+			matches = append(matches, scored{candidate, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+	var out []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"dracula", "monokai", "monokailight", "Python", "python", "python2", "vim"}
+
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "dracla", want: []string{"dracula"}},
+		{value: "DRACULA", want: []string{"dracula"}},
+		{value: "monoka", want: []string{"monokai", "monokailight"}},
+		{value: "pyton", want: []string{"python", "python2"}},
+		{value: "vi", want: []string{"vim"}},
+		{value: "zzzzzz", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, suggest(tt.value, candidates))
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("nyan", "nyan"))
+	assert.Equal(t, 1, levenshtein("dracla", "dracula"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "nyan"))
+	assert.Equal(t, 1, levenshtein("ニャン", "ニャー"))
+}
+
+func TestUnknownValueError(t *testing.T) {
+	o := &options{}
+	assert.EqualError(t, o.validateTheme("dracla"), `unknown theme "dracla", did you mean "dracula"?`)
+	assert.EqualError(t, o.validateTheme("zzzzzz"), "unknown theme \"zzzzzz\" (see `nyan --list-themes`)")
+	assert.EqualError(t, validateLanguage("zzzzzzzzzzzz"), `unknown language "zzzzzzzzzzzz"`)
+	assert.EqualError(t, unknownValueError("theme", "x", []string{"xa", "xb"}, ""), `unknown theme "x", did you mean one of "xa", "xb"?`)
+	assert.NoError(t, o.validateTheme("vim"))
+	assert.NoError(t, validateLanguage("go"))
+}
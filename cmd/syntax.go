@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// nyanrcName is the name of the per-project file of syntax mappings.
+const nyanrcName = ".nyanrc"
+
+// syntaxMapping maps the file names matching a glob to a language.
+type syntaxMapping struct {
+	glob     string
+	language string
+	// dir is the directory of the .nyanrc defining the mapping. Globs with a slash are
+	// matched against the path relative to it.
+	dir string
+}
+
+// parseSyntaxMapping parses a GLOB:LANG mapping.
+func parseSyntaxMapping(s string) (syntaxMapping, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 || i == len(s)-1 {
+		return syntaxMapping{}, fmt.Errorf("invalid syntax mapping %q (expected GLOB:LANG)", s)
+	}
+	m := syntaxMapping{glob: strings.TrimSpace(s[:i]), language: strings.TrimSpace(s[i+1:])}
+	if _, err := filepath.Match(m.glob, ""); err != nil {
+		return syntaxMapping{}, fmt.Errorf("invalid glob %q in syntax mapping: %w", m.glob, err)
+	}
+	if err := validateLanguage(m.language); err != nil {
+		return syntaxMapping{}, err
+	}
+	return m, nil
+}
+
+// match reports whether filename matches the glob of the mapping.
+// Globs without a slash are matched against the base name.
+func (m syntaxMapping) match(filename string) bool {
+	name := filepath.Base(filename)
+	if strings.Contains(m.glob, "/") {
+		name = filepath.ToSlash(filename)
+		if m.dir != "" {
+			abs, err := filepath.Abs(filename)
+			if err != nil {
+				return false
+			}
+			rel, err := filepath.Rel(m.dir, abs)
+			if err != nil {
+				return false
+			}
+			name = filepath.ToSlash(rel)
+		}
+	}
+	ok, _ := filepath.Match(m.glob, name)
+	return ok
+}
+
+// parseSyntaxMappings parses the --map-syntax flag values.
+func parseSyntaxMappings(values []string) ([]syntaxMapping, error) {
+	mappings := make([]syntaxMapping, 0, len(values))
+	for _, value := range values {
+		m, err := parseSyntaxMapping(value)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+// readNyanrc reads the syntax mappings of a .nyanrc file. Each line holds a GLOB:LANG
+// mapping, and lines starting with # are comments.
+func readNyanrc(path string) ([]syntaxMapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mappings []syntaxMapping
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m, err := parseSyntaxMapping(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		m.dir = filepath.Dir(path)
+		mappings = append(mappings, m)
+	}
+	return mappings, scanner.Err()
+}
+
+// nyanrcMappings returns the mappings of the nearest .nyanrc in dir or its parents.
+// The results are cached by directory.
+func (o *options) nyanrcMappings(dir string) ([]syntaxMapping, error) {
+	if mappings, ok := o.nyanrcs[dir]; ok {
+		return mappings, nil
+	}
+	if o.nyanrcs == nil {
+		o.nyanrcs = map[string][]syntaxMapping{}
+	}
+
+	mappings, err := readNyanrc(filepath.Join(dir, nyanrcName))
+	if errors.Is(err, fs.ErrNotExist) {
+		mappings, err = nil, nil
+		if parent := filepath.Dir(dir); parent != dir {
+			mappings, err = o.nyanrcMappings(parent)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.nyanrcs[dir] = mappings
+	return mappings, nil
+}
+
+// mappedLanguage returns the language which filename is mapped to by --map-syntax,
+// or else by the nearest .nyanrc, or else by .gitattributes.
+// It returns "" when filename isn't mapped.
+func (o *options) mappedLanguage(filename string) (string, error) {
+	for _, m := range o.syntaxMappings {
+		if m.match(filename) {
+			o.traceDetect("--map-syntax", "%s:%s", m.glob, m.language)
+			return m.language, nil
+		}
+	}
+	o.traceDetect("--map-syntax", "no match")
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	mappings, err := o.nyanrcMappings(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	for _, m := range mappings {
+		if m.match(filename) {
+			o.traceDetect(nyanrcName, "%s:%s (%s)", m.glob, m.language, filepath.Join(m.dir, nyanrcName))
+			return m.language, nil
+		}
+	}
+	if len(mappings) > 0 {
+		o.traceDetect(nyanrcName, "no match (%s)", filepath.Join(mappings[0].dir, nyanrcName))
+	} else {
+		o.traceDetect(nyanrcName, "not found")
+	}
+	return o.linguistLanguage(filename)
+}
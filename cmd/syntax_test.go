@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyntaxMapping(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		m, err := parseSyntaxMapping("*.tmpl:html")
+
+		assert.NoError(t, err)
+		assert.Equal(t, syntaxMapping{glob: "*.tmpl", language: "html"}, m)
+	})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"*.tmpl", `invalid syntax mapping "*.tmpl" (expected GLOB:LANG)`},
+		{":html", `invalid syntax mapping ":html" (expected GLOB:LANG)`},
+		{"*.tmpl:", `invalid syntax mapping "*.tmpl:" (expected GLOB:LANG)`},
+		{"[*.tmpl:html", `invalid glob "[*.tmpl" in syntax mapping: syntax error in pattern`},
+		{"*.tmpl:htmll", `unknown language "htmll", did you mean one of "html", "phtml", "rhtml"?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := parseSyntaxMapping(tt.in)
+
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestSyntaxMappingMatch(t *testing.T) {
+	tests := []struct {
+		mapping  syntaxMapping
+		filename string
+		want     bool
+	}{
+		{syntaxMapping{glob: "*.tmpl"}, "page.tmpl", true},
+		{syntaxMapping{glob: "*.tmpl"}, filepath.FromSlash("templates/page.tmpl"), true},
+		{syntaxMapping{glob: "Tiltfile"}, filepath.FromSlash("deploy/Tiltfile"), true},
+		{syntaxMapping{glob: "*.jsonnet.TEMPLATE"}, "app.jsonnet.TEMPLATE", true},
+		{syntaxMapping{glob: "*.tmpl"}, "page.html", false},
+		{syntaxMapping{glob: "templates/*.tmpl"}, filepath.FromSlash("templates/page.tmpl"), true},
+		{syntaxMapping{glob: "templates/*.tmpl"}, "page.tmpl", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mapping.glob+" "+tt.filename, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.mapping.match(tt.filename))
+		})
+	}
+}
+
+func TestMappedLanguage(t *testing.T) {
+	o := &options{}
+	o.syntaxMappings, _ = parseSyntaxMappings([]string{"*.conf:toml"})
+
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"testdata/nyanrc/Tiltfile", "python"},
+		{"testdata/nyanrc/page.tmpl", "html"},
+		{"testdata/nyanrc/sub/app.conf", "toml"},
+		{"testdata/dummy.go", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, err := o.mappedLanguage(filepath.FromSlash(tt.filename))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("relative glob", func(t *testing.T) {
+		o := &options{}
+
+		got, err := o.mappedLanguage(filepath.FromSlash("testdata/nyanrc/sub/app.conf"))
+		assert.NoError(t, err)
+		assert.Equal(t, "ini", got)
+
+		got, err = o.mappedLanguage(filepath.FromSlash("testdata/nyanrc/app.conf"))
+		assert.NoError(t, err)
+		assert.Equal(t, "", got)
+	})
+
+	t.Run("invalid .nyanrc", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, ".nyanrc"), []byte("# comment\n*.tmpl\n"), 0o644))
+
+		_, err := (&options{}).mappedLanguage(filepath.Join(dir, "page.tmpl"))
+
+		assert.EqualError(t, err, filepath.Join(dir, ".nyanrc")+`:2: invalid syntax mapping "*.tmpl" (expected GLOB:LANG)`)
+	})
+}
+
+func TestMapSyntaxOption(t *testing.T) {
+	t.Run("from .nyanrc", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color=always", "testdata/nyanrc/Tiltfile"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "\x1b[38;5;186mext://restart_process\x1b[0m")
+	})
+
+	t.Run("--map-syntax", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color=always", "--map-syntax", "*.tmpl:xml", "testdata/nyanrc/page.tmpl"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Contains(t, o.String(), "\x1b[38;5;197m<p\x1b[0m")
+	})
+
+	t.Run("--language wins", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--color=always", "-l", "text", "testdata/nyanrc/Tiltfile"})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.NotContains(t, o.String(), "\x1b[38;5;186m")
+	})
+
+	t.Run("invalid mapping", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--map-syntax", "*.tmpl", "testdata/nyanrc/page.tmpl"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: invalid syntax mapping \"*.tmpl\" (expected GLOB:LANG)\n", e.String())
+		assert.Empty(t, o.String())
+	})
+}
+
+func TestDebugDetectOption(t *testing.T) {
+	var o, e bytes.Buffer
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--debug-detect", "--map-syntax", "*.tmpl:xml", "testdata/nyanrc/page.tmpl", "testdata/dummy.go.unknown"})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Equal(t, `testdata/nyanrc/page.tmpl:
+  --language:     not given
+  --map-syntax:   *.tmpl:xml
+  language:       xml => XML
+testdata/dummy.go.unknown:
+  --language:     not given
+  --map-syntax:   no match
+  .nyanrc:        not found
+  .gitattributes: no linguist attributes
+  modeline:       none
+  shebang:        none
+  filename:       testdata/dummy.go.unknown: no match
+  content:        (candidates: Go 0.50, GDScript3 0.40) => Go
+`, e.String())
+	assert.Contains(t, o.String(), "package main")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/spf13/cobra"
+	"github.com/toshimaru/nyan/styles"
+)
+
+// importOptions holds the flag values of `nyan theme import`.
+type importOptions struct {
+	output  string
+	format  string
+	name    string
+	install bool
+}
+
+func newThemeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "theme",
+		Short: "Manage color themes.",
+	}
+	cmd.AddCommand(newThemeImportCmd())
+	return cmd
+}
+
+func newThemeImportCmd() *cobra.Command {
+	o := &importOptions{}
+	cmd := &cobra.Command{
+		Use:   "import [flags] FILE",
+		Short: "Convert a TextMate (.tmTheme) or VS Code (.json) theme into a nyan theme.",
+		Example: `$ nyan theme import Monokai.tmTheme > monokai.xml
+$ nyan theme import -o dark-plus.yaml dark_plus.json
+$ nyan theme import --install --name house house-theme.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.run,
+	}
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "Write the theme to a file (.xml, .json, .yaml) instead of stdout")
+	cmd.Flags().StringVarP(&o.format, "format", "f", styles.FormatXML, "Format of the theme written to stdout (xml, json, yaml)")
+	cmd.Flags().StringVar(&o.name, "name", "", "Set the theme name (default: name in the file, or the file name)")
+	cmd.Flags().BoolVar(&o.install, "install", false, "Save the theme to the themes directory so that it can be used with --theme")
+	return cmd
+}
+
+func (o *importOptions) run(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	style, err := styles.ImportFile(args[0])
+	if err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	if o.name != "" {
+		style.Name = o.name
+	}
+
+	output := o.output
+	if o.install {
+		path, err := installPath(style.Name)
+		if err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+		output = path
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+	}
+	if output == "" {
+		if err := styles.Save(cmd.OutOrStdout(), style, o.format); err != nil {
+			cmd.PrintErrln("Error:", err)
+			return err
+		}
+		return nil
+	}
+
+	if err := saveTheme(output, style); err != nil {
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+	cmd.PrintErrf("Theme %q written to %s\n", style.Name, output)
+	return nil
+}
+
+// installPath returns the path in the themes directory which a theme named name is
+// installed to. The name is made a plain file name, so that it can't point elsewhere.
+func installPath(name string) (string, error) {
+	dir := configDir()
+	if dir == "" {
+		return "", errors.New("--install: no configuration directory (set $XDG_CONFIG_HOME or $HOME)")
+	}
+	file := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, name)
+	file = strings.TrimLeft(file, ". ")
+	if file == "" {
+		return "", fmt.Errorf("--install: theme name %q can't be used as a file name (use --name)", name)
+	}
+	return filepath.Join(dir, "themes", file+".xml"), nil
+}
+
+func saveTheme(path string, style *chroma.Style) error {
+	format, ok := styles.FormatOf(path)
+	if !ok {
+		return fmt.Errorf("%s: unsupported style file extension %q", path, filepath.Ext(path))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := styles.Save(f, style, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toshimaru/nyan/styles"
+)
+
+const tmThemeFile = "../styles/testdata/import/sample.tmTheme"
+
+func TestThemeImportCommand(t *testing.T) {
+	var o, e bytes.Buffer
+
+	t.Run("stdout", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", tmThemeFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Contains(t, o.String(), `<style name="Sample Tm">`)
+		assert.Contains(t, o.String(), `<entry type="Comment" style="italic #75715e"></entry>`)
+	})
+
+	t.Run("stdout with format and name", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "--format", "yaml", "--name", "house", tmThemeFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Contains(t, o.String(), "name: house\n")
+		assert.Contains(t, o.String(), "Comment: 'italic #75715e'\n")
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		output := filepath.Join(t.TempDir(), "sample.json")
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "-o", output, tmThemeFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, o.String())
+		assert.Contains(t, e.String(), `Theme "Sample Tm" written to `)
+		style, err := styles.LoadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, "Sample Tm", style.Name)
+	})
+
+	t.Run("install", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "--install", "--name", "sample", tmThemeFile})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "nyan", "themes", "sample.xml"))
+	})
+
+	t.Run("install without config dir", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", "")
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		t.Chdir(t.TempDir())
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "--install", filepath.Join(wd, tmThemeFile)})
+		err = rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: --install: no configuration directory (set $XDG_CONFIG_HOME or $HOME)\n", e.String())
+		assert.NoDirExists(t, "themes")
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "testdata/dummyfile"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), `Error: testdata/dummyfile: unsupported theme file extension ""`)
+	})
+
+	t.Run("invalid output extension", func(t *testing.T) {
+		o.Reset()
+		e.Reset()
+		output := filepath.Join(t.TempDir(), "sample.txt")
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"theme", "import", "-o", output, tmThemeFile})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Contains(t, e.String(), `unsupported style file extension ".txt"`)
+		assert.NoFileExists(t, output)
+	})
+}
+
+func TestInstallPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	themes := filepath.Join(dir, "nyan", "themes")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sample Tm", filepath.Join(themes, "Sample Tm.xml")},
+		{"../../evil", filepath.Join(themes, "-..-evil.xml")},
+		{"/etc/passwd", filepath.Join(themes, "-etc-passwd.xml")},
+		{"..", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := installPath(tt.name)
+			if tt.want == "" {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, path)
+		})
+	}
+}
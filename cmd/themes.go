@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/spf13/cobra"
+	"github.com/toshimaru/nyan/styles"
+)
+
+// loadUserThemes loads the theme files in the themes directory of the configuration.
+// They are not registered, so that they only take effect in the running command.
+func loadUserThemes() ([]*chroma.Style, error) {
+	dir := configDir()
+	if dir == "" {
+		return nil, nil
+	}
+	return styles.LoadDir(filepath.Join(dir, "themes"))
+}
+
+// addUserThemes adds the user themes to the themes of the command, and warns about the
+// theme files which fail to load.
+func (o *options) addUserThemes(cmd *cobra.Command) {
+	userThemes, err := loadUserThemes()
+	if err != nil {
+		cmd.PrintErrln("Warning:", err)
+	}
+	if o.themes == nil {
+		o.themes = map[string]*chroma.Style{}
+	}
+	for _, style := range userThemes {
+		o.themes[style.Name] = style
+	}
+}
+
+// themeUsage returns the usage of --theme, which lists the available themes.
+func themeUsage(names []string) string {
+	return fmt.Sprintf("Set color theme for syntax highlighting\nAvailable themes: %s", names)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toshimaru/nyan/styles"
+)
+
+func TestLoadUserThemes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "testdata/config")
+
+	themes, err := loadUserThemes()
+
+	assert.NoError(t, err)
+	require.Len(t, themes, 1)
+	assert.Equal(t, "user-theme", themes[0].Name)
+	assert.NotContains(t, styles.Names(), "user-theme")
+}
+
+func TestUserThemes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "testdata/config")
+	var o, e bytes.Buffer
+
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--theme", "user-theme", "../testdata/output"})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Empty(t, e.String())
+	assert.NotContains(t, styles.Names(), "user-theme")
+}
+
+func TestUserThemesHelp(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "testdata/config")
+	var o, e bytes.Buffer
+
+	rootCmd := NewRootCmd()
+	rootCmd.SetOut(&o)
+	rootCmd.SetErr(&e)
+	rootCmd.SetArgs([]string{"--help"})
+	err := rootCmd.Execute()
+
+	assert.NoError(t, err)
+	assert.Empty(t, e.String())
+	assert.Contains(t, o.String(), "swapoff user-theme vim]")
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toshimaru/nyan/highlight"
+)
+
+// ignoreRule is a line of a .gitignore file.
+type ignoreRule struct {
+	pattern gitPattern
+	// negate is set for patterns starting with `!`, which include the matched files again.
+	negate bool
+}
+
+// ignoreFile is a parsed .gitignore file.
+type ignoreFile struct {
+	// dir is the directory the patterns are relative to.
+	dir   string
+	rules []ignoreRule
+}
+
+// readIgnoreFile reads a gitignore(5) file. It returns nil when the file doesn't exist.
+func readIgnoreFile(path, dir string) (*ignoreFile, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &ignoreFile{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " \t")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if pattern, ok := strings.CutPrefix(line, "!"); ok {
+			rule.negate = true
+			line = pattern
+		}
+		p, err := compileGitPattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		rule.pattern = p
+		file.rules = append(file.rules, rule)
+	}
+	return file, scanner.Err()
+}
+
+// match reports whether the file ignores or includes again the absolute path.
+// ok is false when no pattern matches it.
+func (f *ignoreFile) match(path string, isDir bool) (ignored, ok bool) {
+	rel, err := filepath.Rel(f.dir, path)
+	if err != nil {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, rule := range f.rules {
+		if rule.pattern.match(rel, isDir) {
+			ignored, ok = !rule.negate, true
+		}
+	}
+	return ignored, ok
+}
+
+// dirWalker walks a directory argument for --recursive.
+type dirWalker struct {
+	root string
+	// gitRoot is the working tree containing root, or empty outside a git repository.
+	gitRoot string
+	// ignores are the .gitignore files by the absolute path of their directory.
+	ignores map[string]*ignoreFile
+	// exclude is $GIT_DIR/info/exclude.
+	exclude *ignoreFile
+
+	includes []gitPattern
+	excludes []gitPattern
+}
+
+// newDirWalker returns a walker of root, reading the .gitignore files of its parent
+// directories up to the root of its git repository.
+func (o *options) newDirWalker(root string) (*dirWalker, error) {
+	w := &dirWalker{root: root, ignores: map[string]*ignoreFile{}}
+	for _, glob := range o.include {
+		p, err := compileGitPattern(glob)
+		if err != nil {
+			return nil, fmt.Errorf("--include: %w", err)
+		}
+		w.includes = append(w.includes, p)
+	}
+	for _, glob := range o.exclude {
+		p, err := compileGitPattern(glob)
+		if err != nil {
+			return nil, fmt.Errorf("--exclude: %w", err)
+		}
+		w.excludes = append(w.excludes, p)
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	gitRoot, gitDir := findGitRoot(abs)
+	if gitRoot == "" {
+		return w, nil
+	}
+	w.gitRoot = gitRoot
+	if gitDir != "" {
+		if w.exclude, err = readIgnoreFile(filepath.Join(gitDir, "info", "exclude"), gitRoot); err != nil {
+			return nil, err
+		}
+	}
+	for dir := filepath.Dir(abs); strings.HasPrefix(dir, gitRoot); dir = filepath.Dir(dir) {
+		if err := w.readIgnoreFile(dir); err != nil {
+			return nil, err
+		}
+		if dir == gitRoot {
+			break
+		}
+	}
+	return w, nil
+}
+
+func (w *dirWalker) readIgnoreFile(dir string) error {
+	file, err := readIgnoreFile(filepath.Join(dir, ".gitignore"), dir)
+	if err != nil {
+		return err
+	}
+	if file != nil {
+		w.ignores[dir] = file
+	}
+	return nil
+}
+
+// ignored reports whether the absolute path is ignored by the .gitignore files of its
+// directories, where the nearest file takes precedence, or by $GIT_DIR/info/exclude.
+func (w *dirWalker) ignored(path string, isDir bool) bool {
+	if w.gitRoot == "" {
+		return false
+	}
+	for dir := filepath.Dir(path); strings.HasPrefix(dir, w.gitRoot); dir = filepath.Dir(dir) {
+		if file := w.ignores[dir]; file != nil {
+			if ignored, ok := file.match(path, isDir); ok {
+				return ignored
+			}
+		}
+		if dir == w.gitRoot {
+			break
+		}
+	}
+	if w.exclude != nil {
+		ignored, _ := w.exclude.match(path, isDir)
+		return ignored
+	}
+	return false
+}
+
+// skipped reports whether path, relative to the walked directory, is left out by
+// --include and --exclude.
+func (w *dirWalker) skipped(rel string, isDir bool) bool {
+	for _, p := range w.excludes {
+		if p.match(rel, isDir) {
+			return true
+		}
+	}
+	if isDir || len(w.includes) == 0 {
+		return false
+	}
+	for _, p := range w.includes {
+		if p.match(rel, false) {
+			return false
+		}
+	}
+	return true
+}
+
+// files returns the files in the directory in lexical order, leaving out the .git
+// directory, ignored files, and those left out by --include and --exclude.
+// Errors of the walk are passed to onError, and the walk continues.
+func (w *dirWalker) files(onError func(error)) []string {
+	var files []string
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			onError(err)
+			return nil
+		}
+		if path == w.root {
+			if w.gitRoot != "" {
+				abs, _ := filepath.Abs(path)
+				if err := w.readIgnoreFile(abs); err != nil {
+					onError(err)
+				}
+			}
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		rel, _ := filepath.Rel(w.root, path)
+		abs, _ := filepath.Abs(path)
+		if w.skipped(filepath.ToSlash(rel), d.IsDir()) || w.ignored(abs, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if w.gitRoot != "" {
+				if err := w.readIgnoreFile(abs); err != nil {
+					onError(err)
+				}
+			}
+			return nil
+		}
+		if d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0 && isFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// printDir prints the files in a directory argument with --recursive to w. Binary files
+// are skipped unless --binary tells how to show them.
+func (o *options) printDir(cmd *cobra.Command, w io.Writer, dir string, opts highlight.Options) error {
+	if !o.recursive {
+		err := fmt.Errorf("%s: is a directory (use --recursive to show the files in it)", dir)
+		cmd.PrintErrln("Error:", err)
+		return err
+	}
+
+	var lastErr error
+	onError := func(err error) {
+		cmd.PrintErrln("Error:", err)
+		lastErr = err
+	}
+	walker, err := o.newDirWalker(dir)
+	if err != nil {
+		onError(err)
+		return err
+	}
+
+	o.inDir = true
+	defer func() { o.inDir = false }()
+	for _, filename := range walker.files(onError) {
+		o.startDetectTrace(filename)
+		o.decorator.begin(filename, fileSize(filename))
+		err := o.printFile(w, filename, opts)
+		if endErr := o.decorator.end(err); err == nil {
+			err = endErr
+		}
+		if errors.Is(err, errPagerClosed) {
+			return err
+		}
+		o.matches.report(filename, err)
+		if err != nil && !errors.Is(err, errBinarySkipped) {
+			onError(err)
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		".gitignore": "# build output\nbuild/\n*.log\n!keep.log\n/root.txt\n",
+	})
+	file, err := readIgnoreFile(filepath.Join(root, ".gitignore"), root)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path        string
+		isDir       bool
+		wantIgnored bool
+		wantOK      bool
+	}{
+		{"build", true, true, true},
+		{"build", false, false, false},
+		{"src/app.log", false, true, true},
+		{"keep.log", false, false, true},
+		{"root.txt", false, true, true},
+		{"src/root.txt", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ignored, ok := file.match(filepath.Join(root, tt.path), tt.isDir)
+
+			assert.Equal(t, tt.wantIgnored, ignored)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		file, err := readIgnoreFile(filepath.Join(root, "src", ".gitignore"), root)
+
+		assert.NoError(t, err)
+		assert.Nil(t, file)
+	})
+}
+
+func TestDirWalker(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		".gitignore":        "*.log\ntmp/\n",
+		".git/info/exclude": "secret.txt\n",
+		"README.md":         "# readme\n",
+		"app.log":           "log\n",
+		"secret.txt":        "secret\n",
+		"tmp/cache.go":      "package tmp\n",
+		"src/.gitignore":    "!keep.log\n",
+		"src/keep.log":      "keep\n",
+		"src/main.go":       "package main\n",
+		"src/main_test.go":  "package main\n",
+		"src/vendor/v.go":   "package v\n",
+	})
+	rel := func(files []string) []string {
+		for i, file := range files {
+			file, _ = filepath.Rel(root, file)
+			files[i] = filepath.ToSlash(file)
+		}
+		return files
+	}
+
+	t.Run("gitignore", func(t *testing.T) {
+		w, err := (&options{}).newDirWalker(root)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{".gitignore", "README.md", "src/.gitignore", "src/keep.log", "src/main.go", "src/main_test.go", "src/vendor/v.go"}, rel(w.files(func(err error) { t.Error(err) })))
+	})
+
+	t.Run("ignored directory given explicitly", func(t *testing.T) {
+		w, err := (&options{}).newDirWalker(filepath.Join(root, "tmp"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"tmp/cache.go"}, rel(w.files(func(err error) { t.Error(err) })))
+	})
+
+	t.Run("include and exclude", func(t *testing.T) {
+		w, err := (&options{include: []string{"*.go"}, exclude: []string{"vendor/", "*_test.go"}}).newDirWalker(root)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"src/main.go"}, rel(w.files(func(err error) { t.Error(err) })))
+	})
+
+	t.Run("outside a git repository", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.go\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+		w, err := (&options{}).newDirWalker(dir)
+		require.NoError(t, err)
+
+		assert.Len(t, w.files(func(err error) { t.Error(err) }), 2)
+	})
+
+	t.Run("invalid glob", func(t *testing.T) {
+		_, err := (&options{include: []string{"/"}}).newDirWalker(root)
+
+		assert.EqualError(t, err, "--include: empty pattern")
+	})
+}
+
+func TestRecursiveOption(t *testing.T) {
+	root := setupGitRepo(t, map[string]string{
+		"a.txt":       "first\n",
+		"b/c.txt":     "second",
+		"b/d.txt":     "third\n",
+		"bin.dat":     "\x7fELF\x00\x00\x00\x00",
+		"empty.txt":   "",
+		"src/main.go": "package main\n",
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{"--recursive", "--color=never", "--exclude=src", root})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Empty(t, e.String())
+		assert.Equal(t, filepath.Join(root, "a.txt")+" (6 B, plaintext, UTF-8)\nfirst\n\n"+
+			filepath.Join(root, "b", "c.txt")+" (6 B, plaintext, UTF-8)\nsecond\n\n"+
+			filepath.Join(root, "b", "d.txt")+" (6 B, plaintext, UTF-8)\nthird\n\n"+
+			filepath.Join(root, "empty.txt")+" (0 B, UTF-8)\n", o.String())
+	})
+
+	t.Run("highlight", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"-r", "--color=always", filepath.Join(root, "src")})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(o.String(), "\x1b[1m\x1b[38;5;231m"+filepath.Join(root, "src", "main.go")+"\x1b[0m\x1b[38;5;242m (13 B, Go, UTF-8)\x1b[0m"))
+		assert.Contains(t, o.String(), highlightedGoCode)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		var o bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetArgs([]string{"-r", "--color=never", "--binary=hexdump", "--include=*.dat", root})
+		err := rootCmd.Execute()
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "bin.dat")+" (8 B, binary)\n"+
+			"00000000  7f 45 4c 46 00 00 00 00                           |.ELF....|\n", o.String())
+	})
+
+	t.Run("directory without recursive", func(t *testing.T) {
+		var o, e bytes.Buffer
+		rootCmd := NewRootCmd()
+		rootCmd.SetOut(&o)
+		rootCmd.SetErr(&e)
+		rootCmd.SetArgs([]string{root, "testdata/dummy.go"})
+		err := rootCmd.Execute()
+
+		assert.Error(t, err)
+		assert.Equal(t, "Error: "+root+": is a directory (use --recursive to show the files in it)\n", e.String())
+		assert.NotEmpty(t, o.String())
+	})
+}
@@ -0,0 +1,150 @@
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+)
+
+// BinarySniffSize is the number of bytes at the start of the content which IsBinary
+// needs to tell binary content, the same as git's.
+const BinarySniffSize = 8000
+
+// maxInvalidUTF8Ratio is the ratio of bytes in invalid UTF-8 sequences above which
+// the content is binary.
+const maxInvalidUTF8Ratio = 0.3
+
+// IsBinary reports whether data, the start of some content, looks binary: it contains
+// a NUL byte, or too many bytes which are not valid UTF-8.
+func IsBinary(data []byte) bool {
+	if len(data) > BinarySniffSize {
+		data = data[:BinarySniffSize]
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+
+	invalid := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size <= 1 {
+			// A rune cut off at the end of data is not invalid.
+			if !utf8.FullRune(data[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += max(size, 1)
+	}
+	return len(data) > 0 && float64(invalid)/float64(len(data)) > maxInvalidUTF8Ratio
+}
+
+// hexdumpWidth is the number of bytes shown on each line of a hexdump.
+const hexdumpWidth = 16
+
+// Token types of the byte classes in a hexdump.
+var (
+	hexNull       = chroma.Comment
+	hexPrintable  = chroma.LiteralString
+	hexWhitespace = chroma.NameBuiltin
+	hexControl    = chroma.Keyword
+	hexNonASCII   = chroma.LiteralNumber
+)
+
+// Hexdump writes r as a hexdump like `hexdump -C`, with the offsets and the classes of
+// bytes (NUL, printable ASCII, ASCII whitespace, ASCII control and non-ASCII) colored by
+// the style of the options. Plain, Formatter, Theme and Style are used; the other options are ignored.
+func Hexdump(w io.Writer, r io.Reader, opts Options) error {
+	formatter := formatters.Get(opts.formatter())
+	style := opts.style()
+
+	// Lines are formatted in batches, so that a large input doesn't make a lot of small writes.
+	const linesPerBatch = 256
+	buf := make([]byte, hexdumpWidth*linesPerBatch)
+	offset := 0
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			tokens := hexdumpTokens(buf[:n], offset)
+			offset += n
+			if opts.Plain {
+				if err := writeTokens(w, tokens); err != nil {
+					return err
+				}
+			} else if err := formatter.Format(w, style, chroma.Literator(tokens...)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// hexdumpTokens returns the tokens of the hexdump lines of data, which starts at offset.
+func hexdumpTokens(data []byte, offset int) []chroma.Token {
+	var tokens []chroma.Token
+	add := func(tokenType chroma.TokenType, value string) {
+		tokens = append(tokens, chroma.Token{Type: tokenType, Value: value})
+	}
+
+	for start := 0; start < len(data); start += hexdumpWidth {
+		line := data[start:min(start+hexdumpWidth, len(data))]
+		add(chroma.LineNumbers, fmt.Sprintf("%08x", offset+start))
+		add(chroma.Text, " ")
+		for i := range hexdumpWidth {
+			if i%8 == 0 {
+				add(chroma.Text, " ")
+			}
+			if i >= len(line) {
+				add(chroma.Text, "   ")
+				continue
+			}
+			add(byteClass(line[i]), fmt.Sprintf("%02x", line[i]))
+			add(chroma.Text, " ")
+		}
+
+		add(chroma.Punctuation, " |")
+		var ascii strings.Builder
+		for i, c := range line {
+			ascii.WriteByte(printableByte(c))
+			if i+1 == len(line) || byteClass(line[i+1]) != byteClass(c) {
+				add(byteClass(c), ascii.String())
+				ascii.Reset()
+			}
+		}
+		add(chroma.Punctuation, "|")
+		add(chroma.Text, "\n")
+	}
+	return tokens
+}
+
+func byteClass(c byte) chroma.TokenType {
+	switch {
+	case c == 0:
+		return hexNull
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+		return hexWhitespace
+	case c > 0x20 && c < 0x7f:
+		return hexPrintable
+	case c < 0x80:
+		return hexControl
+	}
+	return hexNonASCII
+}
+
+// printableByte returns c when it's printable ASCII, or else a dot.
+func printableByte(c byte) byte {
+	if c == ' ' || byteClass(c) == hexPrintable {
+		return c
+	}
+	return '.'
+}
@@ -0,0 +1,73 @@
+package highlight
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		desc string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("package main\n"), false},
+		{"UTF-8", []byte("こんにちは、世界\n"), false},
+		{"cut off rune", []byte("こんにちは")[:14], false},
+		{"NUL", []byte("a\x00b"), true},
+		{"NUL after sniff size", append(bytes.Repeat([]byte("a"), BinarySniffSize), 0), false},
+		{"Latin-1 text", []byte("caf\xe9 cr\xe8me br\xfbl\xe9e\n"), false},
+		{"invalid UTF-8", []byte("\xff\xfe\xfd\xfc\x80\x81abc"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsBinary(tt.data))
+		})
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	input := "\x7fELF\x02\x01\x01\x00hello world\n\tcaf\xc3\xa9"
+
+	t.Run("plain", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, Hexdump(&b, strings.NewReader(input), Options{Plain: true}))
+
+		assert.Equal(t, `00000000  7f 45 4c 46 02 01 01 00  68 65 6c 6c 6f 20 77 6f  |.ELF....hello wo|
+00000010  72 6c 64 0a 09 63 61 66  c3 a9                    |rld..caf..|
+`, b.String())
+	})
+
+	t.Run("colored", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, Hexdump(&b, strings.NewReader(input), Options{Theme: "monokai"}))
+
+		out := b.String()
+		assert.Contains(t, out, "\x1b[38;5;242m00\x1b[0m", "NUL")
+		assert.Contains(t, out, "\x1b[38;5;186m45\x1b[0m", "printable")
+		assert.Contains(t, out, "\x1b[38;5;81m7f\x1b[0m", "control")
+		assert.Contains(t, out, "\x1b[38;5;141mc3\x1b[0m", "non-ASCII")
+		assert.Contains(t, out, "\x1b[38;5;186mELF\x1b[0m", "ASCII column")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, Hexdump(&b, strings.NewReader(""), Options{Plain: true}))
+
+		assert.Empty(t, b.String())
+	})
+
+	t.Run("large", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, Hexdump(&b, bytes.NewReader(make([]byte, 10000)), Options{Plain: true}))
+
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		assert.Len(t, lines, 625)
+		assert.True(t, strings.HasPrefix(lines[624], "00002700  00"))
+	})
+}
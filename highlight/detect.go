@@ -0,0 +1,227 @@
+package highlight
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// modelineLines is the number of lines at the start and the end of the text searched
+// for a Vim modeline, the same as Vim's default 'modelines' option.
+const modelineLines = 5
+
+var (
+	vimModeline    = regexp.MustCompile(`(?:^|\s)(?:vim?|Vim|ex):\s*(?:set?\s+)?(.*)`)
+	vimFiletype    = regexp.MustCompile(`(?:^|[\s:])(?:ft|filetype|syn|syntax)=([\w.+-]+)`)
+	emacsModeline  = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+	emacsModeEntry = regexp.MustCompile(`(?i)(?:^|;)\s*mode\s*:\s*([\w.+-]+)`)
+)
+
+// interpreters maps the interpreters of shebang lines, and the Emacs modes, to the
+// languages whose lexers don't know them by name.
+var interpreters = map[string]string{
+	"ash":          "bash",
+	"dash":         "bash",
+	"shell-script": "bash",
+	"node":         "javascript",
+	"nodejs":       "javascript",
+	"js2":          "javascript",
+	"bun":          "typescript",
+	"deno":         "typescript",
+	"ts-node":      "typescript",
+	"cperl":        "perl",
+	"rscript":      "r",
+	"runghc":       "haskell",
+	"runhaskell":   "haskell",
+	"tclsh":        "tcl",
+	"wish":         "tcl",
+}
+
+// Modeline returns the lexer named by a Vim modeline (`vim: set ft=ruby:`) in the first
+// or last lines of text, or by an Emacs modeline (`-*- mode: yaml -*-`) in its first two
+// lines. It returns nil when there is no modeline naming a known language.
+func Modeline(text string) chroma.Lexer {
+	lexer, _ := modeline(text)
+	return lexer
+}
+
+// modeline returns the lexer and the language named by a modeline of text.
+// The lexer is nil when the language is unknown, and the name is empty without a modeline.
+func modeline(text string) (lexer chroma.Lexer, name string) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	for i, line := range lines {
+		if i >= 2 {
+			break
+		}
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			mode := m[1]
+			if strings.Contains(mode, ":") {
+				entry := emacsModeEntry.FindStringSubmatch(mode)
+				if entry == nil {
+					continue
+				}
+				mode = entry[1]
+			}
+			mode = strings.TrimSuffix(strings.ToLower(mode), "-ts")
+			if lexer := lookupLanguage(mode); lexer != nil {
+				return lexer, mode
+			}
+			if name == "" {
+				name = mode
+			}
+		}
+	}
+
+	candidates := lines
+	if len(lines) > modelineLines*2 {
+		candidates = append(lines[:modelineLines:modelineLines], lines[len(lines)-modelineLines:]...)
+	}
+	for _, line := range candidates {
+		m := vimModeline.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if ft := vimFiletype.FindStringSubmatch(m[1]); ft != nil {
+			if lexer := lookupLanguage(ft[1]); lexer != nil {
+				return lexer, ft[1]
+			}
+			if name == "" {
+				name = ft[1]
+			}
+		}
+	}
+	return nil, name
+}
+
+// Shebang returns the lexer of the interpreter in the `#!` line of text, such as
+// `#!/usr/bin/env python3`. It returns nil when there is no shebang naming a known language.
+func Shebang(text string) chroma.Lexer {
+	lexer, _ := shebang(text)
+	return lexer
+}
+
+// shebang returns the lexer and the name of the interpreter in the shebang line of text.
+// The lexer is nil when the interpreter is unknown, and the name is empty without a shebang.
+func shebang(text string) (chroma.Lexer, string) {
+	if !strings.HasPrefix(text, "#!") {
+		return nil, ""
+	}
+	line, _, _ := strings.Cut(text[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// Skip the options and variable assignments of env.
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = filepath.Base(field)
+			break
+		}
+	}
+	if interpreter == "" {
+		return nil, ""
+	}
+	if lexer := lookupLanguage(interpreter); lexer != nil {
+		return lexer, interpreter
+	}
+	// python3.12 or ruby2.7
+	return lookupLanguage(strings.TrimRight(interpreter, "0123456789.")), interpreter
+}
+
+// lookupLanguage returns the lexer of a language name used by editors and interpreters.
+func lookupLanguage(name string) chroma.Lexer {
+	if name == "" {
+		return nil
+	}
+	if alias, ok := interpreters[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	return lexers.Get(name)
+}
+
+// Stages of the lexer detection, in the order they are tried.
+const (
+	StageLexer    = "lexer"
+	StageLanguage = "language"
+	StageModeline = "modeline"
+	StageShebang  = "shebang"
+	StageFilename = "filename"
+	StageContent  = "content"
+	StageFallback = "fallback"
+)
+
+// Detection reports a stage tried to choose the lexer. See Options.OnDetect.
+type Detection struct {
+	Stage string
+	// Input is what the stage looked at, such as the language named by a modeline or the
+	// filename. It's empty when there was nothing to look at.
+	Input string
+	// Candidates are the lexers considered by the filename and content stages, best first.
+	Candidates []Candidate
+	// Lexer is the lexer chosen by the stage, or nil.
+	Lexer chroma.Lexer
+}
+
+// Candidate is a lexer considered by a detection stage. Score is the result of
+// the lexer's content analysis, from 0 to 1, and is zero for the filename stage.
+type Candidate struct {
+	Lexer chroma.Lexer
+	Score float32
+}
+
+func (o Options) report(d Detection) {
+	if o.OnDetect != nil {
+		o.OnDetect(d)
+	}
+}
+
+// filenameCandidates returns the lexers whose filename patterns match filename,
+// in the order of their priorities.
+func filenameCandidates(filename string) []Candidate {
+	base := filepath.Base(filename)
+	var matched chroma.PrioritisedLexers
+	for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+		config := lexer.Config()
+		for _, glob := range slices.Concat(config.Filenames, config.AliasFilenames) {
+			if ok, _ := filepath.Match(glob, base); ok {
+				matched = append(matched, lexer)
+				break
+			}
+		}
+	}
+	sort.Sort(matched)
+
+	candidates := make([]Candidate, len(matched))
+	for i, lexer := range matched {
+		candidates[i] = Candidate{Lexer: lexer}
+	}
+	return candidates
+}
+
+// contentCandidates returns the lexers which analyse text with a positive score, best first.
+func contentCandidates(text string) []Candidate {
+	var candidates []Candidate
+	for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+		if analyser, ok := lexer.(chroma.Analyser); ok {
+			if score := analyser.AnalyseText(text); score > 0 {
+				candidates = append(candidates, Candidate{Lexer: lexer, Score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates
+}
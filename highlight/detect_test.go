@@ -0,0 +1,151 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func name(lexer chroma.Lexer) string {
+	if lexer == nil {
+		return ""
+	}
+	return lexer.Config().Name
+}
+
+func TestModeline(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"# vim: ft=ruby\nputs 1\n", "Ruby"},
+		{"puts 1\n# vim: set filetype=ruby :\n", "Ruby"},
+		{"/* vi: set ts=4 syntax=javascript: */\n", "JavaScript"},
+		{"// ex:ft=cpp\n", "C++"},
+		{"# -*- mode: yaml -*-\nkey: value\n", "YAML"},
+		{"#!/bin/sh\n# -*- mode: python; coding: utf-8 -*-\n", "Python"},
+		{";; -*- emacs-lisp -*-\n", "EmacsLisp"},
+		{"# -*- Mode: shell-script -*-\n", "Bash"},
+		{"# -*- mode: python-ts -*-\n", "Python"},
+		{"# -*- coding: utf-8 -*-\n", ""},
+		{"# vim: ft=unknown-language\n", ""},
+		{"# vim: ts=2 sw=2\n", ""},
+		{"no modeline\n", ""},
+		{"\n\n# -*- mode: yaml -*-\n", ""},
+		{strings.Repeat("\n", 5) + "# vim: ft=ruby\n" + strings.Repeat("\n", 5), ""},
+		{strings.Repeat("\n", 10) + "# vim: ft=ruby\n", "Ruby"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, name(Modeline(tt.text)))
+		})
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"#!/usr/bin/env python3\nimport os\n", "Python"},
+		{"#!/usr/bin/python3.12\n", "Python"},
+		{"#!/bin/bash -e\n", "Bash"},
+		{"#!/bin/sh\n", "Bash"},
+		{"#!/usr/bin/env -S node --harmony\n", "JavaScript"},
+		{"#!/usr/bin/env DEBUG=1 ruby\n", "Ruby"},
+		{"#! /usr/bin/perl -w\n", "Perl"},
+		{"#!/usr/bin/env deno run\n", "TypeScript"},
+		{"#!/usr/bin/env\n", ""},
+		{"#!/usr/local/bin/unknown-interpreter\n", ""},
+		{"#!\n", ""},
+		{"# not a shebang\n", ""},
+		{" #!/bin/sh\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, name(Shebang(tt.text)))
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		desc     string
+		text     string
+		filename string
+		want     string
+	}{
+		{"modeline over shebang", "#!/bin/sh\n# vim: ft=python\n", "deploy", "Python"},
+		{"shebang over filename", "#!/usr/bin/env ruby\n", "deploy.sh", "Ruby"},
+		{"filename", "echo hello\n", "deploy.sh", "Bash"},
+		{"content", "package main\n", "main.unknown", "Go"},
+		{"fallback", "hello\n", "README.unknown", "fallback"},
+		{"no filename", "#!/bin/bash\n", "", "Bash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, name(Options{Filename: tt.filename}.detect(tt.text)))
+		})
+	}
+}
+
+func TestOnDetect(t *testing.T) {
+	detect := func(t *testing.T, text string, opts Options) []Detection {
+		t.Helper()
+		var got []Detection
+		opts.OnDetect = func(d Detection) { got = append(got, d) }
+		highlightString(t, text, opts)
+		return got
+	}
+	stages := func(detections []Detection) []string {
+		var out []string
+		for _, d := range detections {
+			out = append(out, d.Stage+":"+d.Input+":"+name(d.Lexer))
+		}
+		return out
+	}
+
+	t.Run("language", func(t *testing.T) {
+		got := detect(t, goCode, Options{Language: "go", Filename: "main.txt"})
+
+		assert.Equal(t, []string{"language:go:Go"}, stages(got))
+	})
+
+	t.Run("shebang", func(t *testing.T) {
+		got := detect(t, "#!/usr/bin/env ruby\n", Options{Filename: "deploy"})
+
+		assert.Equal(t, []string{"modeline::", "shebang:ruby:Ruby"}, stages(got))
+	})
+
+	t.Run("filename", func(t *testing.T) {
+		got := detect(t, goCode, Options{Filename: "main.go"})
+
+		assert.Equal(t, []string{"modeline::", "shebang::", "filename:main.go:Go"}, stages(got))
+		assert.Equal(t, "Go", name(got[2].Candidates[0].Lexer))
+	})
+
+	t.Run("content", func(t *testing.T) {
+		got := detect(t, goCode, Options{Filename: "main.unknown"})
+
+		assert.Equal(t, []string{"modeline::", "shebang::", "filename:main.unknown:", "content::Go"}, stages(got))
+		assert.Empty(t, got[2].Candidates)
+		assert.Equal(t, "Go", name(got[3].Candidates[0].Lexer))
+		assert.Greater(t, got[3].Candidates[0].Score, float32(0))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		got := detect(t, "hello\n", Options{})
+
+		assert.Equal(t, []string{"modeline::", "shebang::", "content::", "fallback::fallback"}, stages(got))
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		var got []Detection
+		out := highlightString(t, "#!/bin/sh\necho\n", Options{Plain: true, OnDetect: func(d Detection) { got = append(got, d) }})
+
+		assert.Equal(t, "#!/bin/sh\necho\n", out)
+		assert.Equal(t, []string{"modeline::", "shebang:sh:Bash"}, stages(got))
+	})
+}
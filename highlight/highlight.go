@@ -0,0 +1,295 @@
+// Package highlight provides nyan's syntax highlighting for use from Go programs.
+//
+//	err := highlight.Highlight(os.Stdout, f, highlight.Options{
+//		Theme:    "dracula",
+//		Filename: "main.go",
+//		Number:   true,
+//	})
+package highlight
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/toshimaru/nyan/styles"
+)
+
+// DefaultFormatter is the formatter used when Options.Formatter is empty.
+const DefaultFormatter = "terminal256"
+
+// Options of Highlight. The zero value highlights with the fallback style,
+// a lexer analysed from the content, and the DefaultFormatter.
+type Options struct {
+	// Theme is the name of a style in the styles registry.
+	// Unknown names fall back to styles.Fallback.
+	Theme string
+	// Style is used instead of Theme when set.
+	Style *chroma.Style
+
+	// Language is the name, alias or file extension of a lexer.
+	// Unknown languages are ignored, and the lexer is chosen as if it was empty.
+	Language string
+	// Lexer is used instead of Language when set.
+	Lexer chroma.Lexer
+	// Filename is used to choose the lexer when neither Lexer nor Language is set,
+	// and the content has neither a modeline nor a shebang line naming a language.
+	// When it doesn't match any lexer, or it's empty, the lexer is chosen by analysing the content.
+	Filename string
+
+	// Formatter is the name of a chroma formatter, such as "terminal16m".
+	Formatter string
+	// Plain disables highlighting, so that the content is copied as is.
+	Plain bool
+	// Number prefixes each line with its line number.
+	Number bool
+	// LineRanges selects the lines shown when it's not empty. The whole content is still
+	// tokenised, and Number shows the line numbers in the content.
+	LineRanges []LineRange
+	// HighlightLines are painted with the background of the LineHighlight entry of the
+	// style. They are ignored in Plain mode.
+	HighlightLines []LineRange
+	// LineLink returns the URL which the number of a line links to with an OSC 8 hyperlink
+	// when Number is set, or "" for no link. No links are written in Plain mode.
+	LineLink func(line int) string
+	// RegularFile tells that r is read from a regular file, as when it's an *os.File of one,
+	// whose content is available without waiting. Otherwise, the output is written each
+	// time r runs dry, so that it keeps up with slow producers.
+	RegularFile bool
+	// Patterns are matched line by line, and their matches are drawn in a match style over
+	// the syntax colors, except in Plain mode.
+	Patterns []*regexp.Regexp
+
+	// OnMatch is called for each match of Patterns in the lines written.
+	OnMatch func()
+	// OnDetect is called for each stage tried to choose the lexer, to explain the choice.
+	OnDetect func(Detection)
+}
+
+// Highlight reads r and writes it to w with syntax highlighting.
+//
+// The input is processed in chunks of lines, so output starts right away and memory
+// use stays bounded for large or never-ending inputs.
+func Highlight(w io.Writer, r io.Reader, opts Options) (err error) {
+	// The formatters don't return the errors of w, so they are kept to stop reading r.
+	ew := &errWriter{w: w}
+	w = ew
+	var nw *NumberWriter
+	if opts.Number {
+		nw = NewNumberWriter(w)
+		if !opts.Plain {
+			nw.Link = opts.LineLink
+		}
+		w = nw
+		defer func() {
+			if flushErr := nw.Flush(); err == nil {
+				err = flushErr
+			}
+		}()
+	}
+
+	style := opts.style()
+	matches := opts.newMatcher(style)
+	if matches != nil && !opts.Plain {
+		style = matchStyle(style)
+	}
+	lines := opts.newLineSelector(style, nw)
+	if opts.Plain && opts.OnDetect == nil && lines == nil && matches == nil {
+		_, err = io.Copy(w, r)
+		return err
+	}
+
+	formatter := formatters.Get(opts.formatter())
+	lexer := opts.lexer()
+	chunks := newChunkReader(r)
+	if opts.RegularFile {
+		chunks.flushOnIdle = false
+	}
+	var (
+		// tokens are those of the chunk closed at a boundary, which were made to find it.
+		tokens []chroma.Token
+		// context is the text since the lexer was last in its root state, when a chunk was
+		// closed out of it because the input ran dry. It's lexed again in front of the next
+		// chunk, so that the chunk is lexed in the state it starts in.
+		context string
+	)
+	if !opts.Plain {
+		chunks.boundary = func(chunk []byte) bool {
+			if lexer == nil {
+				lexer = opts.detect(string(chunk))
+			}
+			lexed, ok := rootStateTokens(lexer, context+string(chunk))
+			if ok {
+				tokens = lexed
+			}
+			return ok
+		}
+	}
+	for {
+		chunk, err := chunks.Next()
+		if len(chunk) > 0 {
+			if lexer == nil {
+				lexer = opts.detect(string(chunk))
+			}
+			if !opts.Plain {
+				text, root := context+string(chunk), tokens != nil
+				if !root {
+					tokens, root = rootStateTokens(lexer, text)
+				}
+				tokens = skipTokens(tokens, len(context))
+				context = ""
+				if !root && len(text) < chunkSize {
+					context = text
+				}
+			}
+			if formatErr := opts.format(w, formatter, style, lexer, chunk, tokens, lines, matches); formatErr != nil {
+				return formatErr
+			}
+			if ew.err != nil {
+				return ew.err
+			}
+		}
+		tokens = nil
+		if err == io.EOF || lines != nil && lines.done() {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// errWriter keeps the first error of w, and fails all writes after it.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+// format writes a chunk highlighted by the lexer, or as is in Plain mode, where the
+// lexer is detected only to be reported to OnDetect. The chunk is tokenised unless its
+// tokens are given. Only the lines selected by lines are written unless it's nil, and
+// its highlighted lines are written in its band style.
+// The matches of matches are overlaid on the written lines, or only counted in Plain mode.
+func (o Options) format(w io.Writer, formatter chroma.Formatter, style *chroma.Style, lexer chroma.Lexer, chunk []byte, tokens []chroma.Token, lines *lineSelector, matches *matcher) error {
+	if o.Plain {
+		if lines != nil {
+			return lines.write(plainLines(string(chunk)), func(tokens []chroma.Token, _ bool) error {
+				matches.count(tokensText(tokens))
+				return writeTokens(w, tokens)
+			})
+		}
+		matches.count(string(chunk))
+		_, err := w.Write(chunk)
+		return err
+	}
+	if tokens == nil {
+		iterator, err := lexer.Tokenise(nil, string(chunk))
+		if err != nil {
+			return err
+		}
+		tokens = iterator.Tokens()
+	}
+	if lines != nil {
+		return lines.write(chroma.SplitTokensIntoLines(tokens), func(tokens []chroma.Token, highlighted bool) error {
+			if matches != nil {
+				tokens = matches.overlay(tokens)
+			}
+			if highlighted {
+				return formatter.Format(w, lines.band, chroma.Literator(tokens...))
+			}
+			return formatter.Format(w, style, chroma.Literator(tokens...))
+		})
+	}
+	if matches != nil {
+		tokens = matches.overlay(tokens)
+	}
+	return formatter.Format(w, style, chroma.Literator(tokens...))
+}
+
+// Analyse chooses a lexer for text by its content, or returns the fallback lexer.
+func Analyse(text string) chroma.Lexer {
+	if lexer := lexers.Analyse(text); lexer != nil {
+		return lexer
+	}
+	return lexers.Fallback
+}
+
+func (o Options) style() *chroma.Style {
+	if o.Style != nil {
+		return o.Style
+	}
+	return styles.Get(o.Theme)
+}
+
+func (o Options) formatter() string {
+	if o.Formatter != "" {
+		return o.Formatter
+	}
+	return DefaultFormatter
+}
+
+// lexer returns the lexer chosen by the options, or nil when it has to be detected from the content.
+func (o Options) lexer() chroma.Lexer {
+	if o.Lexer != nil {
+		o.report(Detection{Stage: StageLexer, Lexer: o.Lexer})
+		return o.Lexer
+	}
+	if o.Language != "" {
+		lexer := lexers.Get(o.Language)
+		o.report(Detection{Stage: StageLanguage, Input: o.Language, Lexer: lexer})
+		return lexer
+	}
+	return nil
+}
+
+// detect chooses a lexer for the first chunk of the content by its modeline, its shebang
+// line, the Filename, and finally by analysing it.
+func (o Options) detect(text string) chroma.Lexer {
+	lexer, name := modeline(text)
+	o.report(Detection{Stage: StageModeline, Input: name, Lexer: lexer})
+	if lexer != nil {
+		return lexer
+	}
+
+	lexer, name = shebang(text)
+	o.report(Detection{Stage: StageShebang, Input: name, Lexer: lexer})
+	if lexer != nil {
+		return lexer
+	}
+
+	if o.Filename != "" {
+		lexer = lexers.Match(o.Filename)
+		d := Detection{Stage: StageFilename, Input: o.Filename, Lexer: lexer}
+		if o.OnDetect != nil {
+			d.Candidates = filenameCandidates(o.Filename)
+		}
+		o.report(d)
+		if lexer != nil {
+			return lexer
+		}
+	}
+
+	lexer = lexers.Analyse(text)
+	d := Detection{Stage: StageContent, Lexer: lexer}
+	if o.OnDetect != nil {
+		d.Candidates = contentCandidates(text)
+	}
+	o.report(d)
+	if lexer != nil {
+		return lexer
+	}
+
+	o.report(Detection{Stage: StageFallback, Lexer: lexers.Fallback})
+	return lexers.Fallback
+}
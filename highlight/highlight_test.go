@@ -0,0 +1,212 @@
+package highlight
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toshimaru/nyan/styles"
+)
+
+const (
+	goCode            = "package main\n"
+	highlightedGoCode = "\x1b[38;5;197mpackage\x1b[0m"
+	plainGoCode       = "\x1b[38;5;231mpackage main\x1b[0m"
+)
+
+func highlightString(t *testing.T, input string, opts Options) string {
+	t.Helper()
+	var b bytes.Buffer
+	require.NoError(t, Highlight(&b, strings.NewReader(input), opts))
+	return b.String()
+}
+
+func TestHighlight(t *testing.T) {
+	t.Run("analyse content", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("language", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Language: "go", Filename: "main.txt"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("unknown language", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Language: "invalid_lang", Filename: "main.go"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("lexer", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Lexer: lexers.Get("go"), Language: "text"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("filename", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Filename: "main.go"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("unknown filename", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Filename: "main.unknown"})
+
+		assert.Contains(t, out, highlightedGoCode)
+	})
+
+	t.Run("text language", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Language: "text", Filename: "main.go"})
+
+		assert.Contains(t, out, plainGoCode)
+	})
+
+	t.Run("shebang", func(t *testing.T) {
+		out := highlightString(t, "#!/usr/bin/env python3\nimport os\n", Options{Theme: "monokai", Filename: "bin/deploy"})
+
+		assert.Contains(t, out, "\x1b[38;5;197mimport\x1b[0m")
+	})
+
+	t.Run("modeline wins over filename", func(t *testing.T) {
+		out := highlightString(t, "# vim: ft=ruby\nx = nil\n", Options{Theme: "monokai", Filename: "build.sh"})
+
+		assert.Contains(t, out, "\x1b[38;5;81mnil\x1b[0m")
+	})
+
+	t.Run("theme", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "vim", Language: "go"})
+
+		assert.Contains(t, out, "\x1b[38;5;164mpackage\x1b[0m")
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "invalid", Language: "go"})
+
+		assert.Contains(t, out, "\x1b[1m\x1b[38;5;231mpackage")
+	})
+
+	t.Run("style", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Style: styles.Vim, Language: "go"})
+
+		assert.Contains(t, out, "\x1b[38;5;164mpackage\x1b[0m")
+	})
+
+	t.Run("formatter", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Language: "go", Formatter: "terminal16m"})
+
+		assert.Contains(t, out, "\x1b[38;2;249;38;114mpackage\x1b[0m")
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		out := highlightString(t, goCode, Options{Theme: "monokai", Language: "go", Plain: true})
+
+		assert.Equal(t, goCode, out)
+	})
+
+	t.Run("number", func(t *testing.T) {
+		out := highlightString(t, "a\nb", Options{Plain: true, Number: true})
+
+		assert.Equal(t, "     1\ta\n     2\tb", out)
+	})
+
+	t.Run("line link", func(t *testing.T) {
+		link := func(line int) string { return fmt.Sprintf("file:///main.go#%d", line) }
+		out := highlightString(t, "a\n", Options{Language: "text", Number: true, LineLink: link})
+
+		assert.True(t, strings.HasPrefix(out, "\x1b]8;;file:///main.go#1\x1b\\     1\x1b]8;;\x1b\\\t"))
+	})
+
+	t.Run("line link in plain mode", func(t *testing.T) {
+		link := func(line int) string { return "file:///main.go" }
+		out := highlightString(t, "a", Options{Plain: true, Number: true, LineLink: link})
+
+		assert.Equal(t, "     1\ta", out)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		err := Highlight(&bytes.Buffer{}, errorReader{}, Options{})
+
+		assert.EqualError(t, err, "read error")
+	})
+}
+
+func TestNumberWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewNumberWriter(&b)
+	w.Write([]byte("first"))
+	w.Write([]byte(" line\nsecond line\nthird"))
+	w.Write([]byte("\x1b[0m\n\x1b[0m"))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "     1\tfirst line\n     2\tsecond line\n     3\tthird\x1b[0m\n\x1b[0m", b.String())
+
+	t.Run("link", func(t *testing.T) {
+		var b bytes.Buffer
+		w := NewNumberWriter(&b)
+		w.Link = func(line int) string {
+			if line == 1 {
+				return ""
+			}
+			return fmt.Sprintf("file:///main.go#%d", line)
+		}
+		w.Write([]byte("first\nsecond"))
+		require.NoError(t, w.Flush())
+
+		assert.Equal(t, "     1\tfirst\n\x1b]8;;file:///main.go#2\x1b\\     2\x1b]8;;\x1b\\\tsecond", b.String())
+	})
+}
+
+func TestHyperlink(t *testing.T) {
+	assert.Equal(t, "\x1b]8;;file:///main.go\x1b\\main.go\x1b]8;;\x1b\\", Hyperlink("file:///main.go", "main.go"))
+}
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+// failingWriter fails all writes after the first size bytes.
+type failingWriter struct {
+	size int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.size <= 0 {
+		return 0, errors.New("closed")
+	}
+	w.size -= len(p)
+	return len(p), nil
+}
+
+func TestHighlightWriteError(t *testing.T) {
+	input := strings.Repeat("x = 1\n", 3*maxChunkSize/6)
+	for _, opts := range []Options{{Language: "text"}, {Language: "text", Number: true}} {
+		r := &countingReader{r: strings.NewReader(input)}
+		err := Highlight(&failingWriter{size: 10}, r, opts)
+
+		assert.EqualError(t, err, "closed")
+		assert.Less(t, r.n, len(input))
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
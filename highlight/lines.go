@@ -0,0 +1,176 @@
+package highlight
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// LineRange is a range of lines from Start to End, both inclusive and 1-based.
+// A zero Start or End leaves the range open at that side.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether the line is in the range.
+func (r LineRange) Contains(line int) bool {
+	return line >= r.Start && (r.End == 0 || line <= r.End)
+}
+
+// eraseLine is the escape sequence erasing the rest of a line, which paints it with the
+// current background color.
+const eraseLine = "\x1b[K"
+
+// lineSelector writes the lines of the content in Options.LineRanges, chunk by chunk,
+// numbering them by their lines in the content, and tells the lines of
+// Options.HighlightLines apart.
+type lineSelector struct {
+	ranges     []LineRange
+	highlights []LineRange
+	numbers    *NumberWriter
+	// band is the style of the highlighted lines.
+	band *chroma.Style
+	// line is the line number of the first line of the next chunk.
+	line int
+}
+
+func (o Options) newLineSelector(style *chroma.Style, numbers *NumberWriter) *lineSelector {
+	if len(o.LineRanges) == 0 && (len(o.HighlightLines) == 0 || o.Plain) {
+		return nil
+	}
+	s := &lineSelector{ranges: o.LineRanges, numbers: numbers, line: 1}
+	if numbers != nil && len(o.LineRanges) > 0 {
+		numbers.selective = true
+	}
+	if !o.Plain {
+		s.highlights = o.HighlightLines
+		s.band = bandStyle(style)
+	}
+	return s
+}
+
+func (s *lineSelector) selected(line int) bool {
+	return len(s.ranges) == 0 || inRanges(s.ranges, line)
+}
+
+func (s *lineSelector) highlighted(line int) bool {
+	return inRanges(s.highlights, line)
+}
+
+func inRanges(ranges []LineRange, line int) bool {
+	return slices.ContainsFunc(ranges, func(r LineRange) bool { return r.Contains(line) })
+}
+
+// done reports whether no more lines are selected after the lines written so far.
+func (s *lineSelector) done() bool {
+	if len(s.ranges) == 0 {
+		return false
+	}
+	for _, r := range s.ranges {
+		if r.End == 0 || r.End >= s.line {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes the selected lines of a chunk, each run of consecutive lines, which are
+// all highlighted or not, by a single call of format.
+func (s *lineSelector) write(lines [][]chroma.Token, format func(tokens []chroma.Token, highlighted bool) error) error {
+	for i := 0; i < len(lines); {
+		if !s.selected(s.line + i) {
+			i++
+			continue
+		}
+		highlighted := s.highlighted(s.line + i)
+		end := i + 1
+		for end < len(lines) && s.selected(s.line+end) && s.highlighted(s.line+end) == highlighted {
+			end++
+		}
+		if s.numbers != nil {
+			s.numbers.currentLine = uint64(s.line + i)
+		}
+		run := lines[i:end]
+		if highlighted {
+			run = bandLines(run)
+		}
+		if err := format(slices.Concat(run...), highlighted); err != nil {
+			return err
+		}
+		i = end
+	}
+
+	s.line += len(lines)
+	if len(lines) > 0 {
+		last := lines[len(lines)-1]
+		if !strings.HasSuffix(last[len(last)-1].Value, "\n") {
+			// The chunk ends in the middle of a line, which the next chunk continues.
+			s.line--
+		}
+	}
+	return nil
+}
+
+// bandStyle returns the style of highlighted lines, which is the style with the
+// background of its LineHighlight entry. The entries of matches are kept as they are.
+func bandStyle(style *chroma.Style) *chroma.Style {
+	background := style.Get(chroma.LineHighlight).Background
+	builder := style.Builder()
+	for _, ttype := range append(style.Types(), chroma.Text) {
+		if ttype >= matchOffset {
+			continue
+		}
+		entry := style.Get(ttype)
+		entry.Background = background
+		builder.AddEntry(ttype, entry)
+	}
+	band, err := builder.Build()
+	if err != nil {
+		return style
+	}
+	return band
+}
+
+// bandLines returns the lines with the rest of each line erased before its line break,
+// so that the background of highlighted lines spans the terminal width.
+func bandLines(lines [][]chroma.Token) [][]chroma.Token {
+	banded := make([][]chroma.Token, len(lines))
+	for i, line := range lines {
+		last := line[len(line)-1]
+		value, newline := strings.CutSuffix(last.Value, "\n")
+		line = slices.Clone(line[:len(line)-1])
+		if value != "" {
+			line = append(line, chroma.Token{Type: last.Type, Value: value})
+		}
+		line = append(line, chroma.Token{Type: chroma.Text, Value: eraseLine})
+		if newline {
+			line = append(line, chroma.Token{Type: chroma.Text, Value: "\n"})
+		}
+		banded[i] = line
+	}
+	return banded
+}
+
+// plainLines splits text into lines of a single text token.
+func plainLines(text string) [][]chroma.Token {
+	var lines [][]chroma.Token
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			lines = append(lines, []chroma.Token{{Type: chroma.Text, Value: line}})
+		}
+	}
+	return lines
+}
+
+// writeTokens writes the values of tokens as is.
+func writeTokens(w io.Writer, tokens []chroma.Token) error {
+	for _, token := range tokens {
+		if _, err := io.WriteString(w, token.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
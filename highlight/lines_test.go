@@ -0,0 +1,86 @@
+package highlight
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineRange(t *testing.T) {
+	assert.True(t, LineRange{Start: 2, End: 3}.Contains(2))
+	assert.True(t, LineRange{Start: 2, End: 3}.Contains(3))
+	assert.False(t, LineRange{Start: 2, End: 3}.Contains(4))
+	assert.True(t, LineRange{Start: 2}.Contains(100))
+	assert.True(t, LineRange{End: 3}.Contains(1))
+}
+
+func TestLineRanges(t *testing.T) {
+	const text = "a\nb\nc\nd\ne\n"
+
+	t.Run("plain", func(t *testing.T) {
+		out := highlightString(t, text, Options{Plain: true, LineRanges: []LineRange{{Start: 2, End: 3}, {Start: 5}}})
+
+		assert.Equal(t, "b\nc\ne\n", out)
+	})
+
+	t.Run("number", func(t *testing.T) {
+		out := highlightString(t, text, Options{Plain: true, Number: true, LineRanges: []LineRange{{End: 1}, {Start: 4, End: 4}}})
+
+		assert.Equal(t, "     1\ta\n     4\td\n", out)
+	})
+
+	t.Run("multi-line comment", func(t *testing.T) {
+		out := highlightString(t, "/* a\nb */\nint x;\n", Options{Theme: "monokai", Language: "c", LineRanges: []LineRange{{Start: 2, End: 2}}})
+
+		assert.Equal(t, "\x1b[38;5;242mb */\x1b[0m\x1b[38;5;231m\x1b[0m\n", out)
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		var b strings.Builder
+		for range chunkSize / 4 {
+			b.WriteString("ab\n\n")
+		}
+		b.WriteString("last\n")
+		out := highlightString(t, b.String(), Options{Plain: true, Number: true, LineRanges: []LineRange{{Start: chunkSize/2 + 1}}})
+
+		assert.Equal(t, fmt.Sprintf("%6d\tlast\n", chunkSize/2+1), out)
+	})
+}
+
+func TestHighlightLines(t *testing.T) {
+	const text = "a\nb\nc\n"
+	const line = "\x1b[38;5;231m%s\x1b[0m\x1b[38;5;231m\x1b[0m\n"
+	const band = "\x1b[38;5;231m\x1b[48;5;237m%s\x1b[0m\x1b[38;5;231m\x1b[48;5;237m\x1b[K\x1b[0m\x1b[38;5;231m\x1b[48;5;237m\x1b[0m\n"
+
+	t.Run("band", func(t *testing.T) {
+		out := highlightString(t, text, Options{Theme: "monokai", Language: "text", HighlightLines: []LineRange{{Start: 2, End: 2}}})
+
+		assert.Equal(t, fmt.Sprintf(line+band+line, "a", "b", "c"), out)
+	})
+
+	t.Run("number", func(t *testing.T) {
+		out := highlightString(t, text, Options{Theme: "monokai", Language: "text", Number: true, HighlightLines: []LineRange{{Start: 2}}})
+
+		assert.Equal(t, fmt.Sprintf("     1\t"+line+"     2\t"+band+"     3\t"+band+"     4\t", "a", "b", "c"), out)
+	})
+
+	t.Run("line ranges", func(t *testing.T) {
+		out := highlightString(t, text, Options{Theme: "monokai", Language: "text", Number: true, LineRanges: []LineRange{{Start: 2}}, HighlightLines: []LineRange{{End: 2}}})
+
+		assert.Equal(t, fmt.Sprintf("     2\t"+band+"     3\t"+line, "b", "c"), out)
+	})
+
+	t.Run("truecolor", func(t *testing.T) {
+		out := highlightString(t, "a", Options{Theme: "monokai", Language: "text", Formatter: "terminal16m", HighlightLines: []LineRange{{Start: 1}}})
+
+		assert.Equal(t, "\x1b[38;2;248;248;242m\x1b[48;2;60;61;56ma\x1b[0m\x1b[38;2;248;248;242m\x1b[48;2;60;61;56m\x1b[K\x1b[0m", out)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		out := highlightString(t, text, Options{Plain: true, HighlightLines: []LineRange{{Start: 2, End: 2}}})
+
+		assert.Equal(t, text, out)
+	})
+}
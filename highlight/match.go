@@ -0,0 +1,136 @@
+package highlight
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// matchOffset is added to the token types of the matches of Options.Patterns, which
+// keeps their categories apart from those of the other tokens.
+const matchOffset chroma.TokenType = 1_000_000
+
+// matchEntry is the style of the matches, drawn over the syntax colors.
+var matchEntry = chroma.MustParseStyleEntry("bold #000000 bg:#ffd700")
+
+// matcher finds the matches of Options.Patterns in the highlighted text.
+type matcher struct {
+	patterns []*regexp.Regexp
+	onMatch  func()
+	// style is the style the token types of the matches are chosen from.
+	style *chroma.Style
+}
+
+func (o Options) newMatcher(style *chroma.Style) *matcher {
+	if len(o.Patterns) == 0 {
+		return nil
+	}
+	return &matcher{patterns: o.Patterns, onMatch: o.OnMatch, style: style}
+}
+
+// find returns the sorted spans of the matches in text, which are matched line by
+// line, and reports each match to OnMatch. Empty matches are left out.
+func (m *matcher) find(text string) [][2]int {
+	if m == nil {
+		return nil
+	}
+	var spans [][2]int
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		content := strings.TrimSuffix(line, "\n")
+		for _, p := range m.patterns {
+			for _, loc := range p.FindAllStringIndex(content, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				spans = append(spans, [2]int{offset + loc[0], offset + loc[1]})
+				if m.onMatch != nil {
+					m.onMatch()
+				}
+			}
+		}
+		offset += len(line)
+	}
+	slices.SortFunc(spans, func(a, b [2]int) int { return a[0] - b[0] })
+	return spans
+}
+
+// overlay returns the tokens with the parts in the matches split off into the token
+// types of the match style.
+func (m *matcher) overlay(tokens []chroma.Token) []chroma.Token {
+	spans := m.find(tokensText(tokens))
+	if len(spans) == 0 {
+		return tokens
+	}
+
+	var out []chroma.Token
+	end := 0
+	for _, token := range tokens {
+		base := end
+		end += len(token.Value)
+		for start := base; start < end; {
+			// Skip the matches ending before the rest of the token.
+			for len(spans) > 0 && spans[0][1] <= start {
+				spans = spans[1:]
+			}
+			cut, matched := end, false
+			if len(spans) > 0 {
+				if spans[0][0] <= start {
+					cut, matched = min(end, spans[0][1]), true
+				} else {
+					cut = min(end, spans[0][0])
+				}
+			}
+			piece := chroma.Token{Type: token.Type, Value: token.Value[start-base : cut-base]}
+			if matched {
+				piece.Type = m.matchType(token.Type)
+			}
+			out = append(out, piece)
+			start = cut
+		}
+	}
+	return out
+}
+
+// matchType returns the token type of a match in a token of ttype, which is that of
+// ttype or its nearest category in the style.
+func (m *matcher) matchType(ttype chroma.TokenType) chroma.TokenType {
+	for _, t := range []chroma.TokenType{ttype, ttype.SubCategory(), ttype.Category()} {
+		if m.style.Has(t) {
+			return t + matchOffset
+		}
+	}
+	return chroma.Text + matchOffset
+}
+
+// count reports the matches in text to OnMatch, for text written without colors.
+func (m *matcher) count(text string) {
+	if m != nil && m.onMatch != nil {
+		m.find(text)
+	}
+}
+
+func tokensText(tokens []chroma.Token) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString(token.Value)
+	}
+	return b.String()
+}
+
+// matchStyle returns the style with the match entry for each of its token types.
+func matchStyle(style *chroma.Style) *chroma.Style {
+	builder := style.Builder()
+	for _, ttype := range append(style.Types(), chroma.Text) {
+		if ttype < matchOffset {
+			builder.AddEntry(ttype+matchOffset, matchEntry)
+		}
+	}
+	matched, err := builder.Build()
+	if err != nil {
+		return style
+	}
+	return matched
+}
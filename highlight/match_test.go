@@ -0,0 +1,80 @@
+package highlight
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/toshimaru/nyan/styles"
+)
+
+func TestMatcherOverlay(t *testing.T) {
+	m := Options{Patterns: []*regexp.Regexp{regexp.MustCompile("ckage m|ai"), regexp.MustCompile("x*")}}.newMatcher(styles.Get("monokai"))
+	tokens := []chroma.Token{
+		{Type: chroma.KeywordNamespace, Value: "package"},
+		{Type: chroma.Text, Value: " "},
+		{Type: chroma.NameOther, Value: "main"},
+		{Type: chroma.Text, Value: "\n"},
+	}
+
+	assert.Equal(t, []chroma.Token{
+		{Type: chroma.KeywordNamespace, Value: "pa"},
+		{Type: chroma.KeywordNamespace + matchOffset, Value: "ckage"},
+		{Type: chroma.Text + matchOffset, Value: " "},
+		{Type: chroma.NameOther + matchOffset, Value: "m"},
+		{Type: chroma.NameOther + matchOffset, Value: "ai"},
+		{Type: chroma.NameOther, Value: "n"},
+		{Type: chroma.Text, Value: "\n"},
+	}, m.overlay(tokens))
+}
+
+func TestMatcherMatchType(t *testing.T) {
+	m := Options{Patterns: []*regexp.Regexp{regexp.MustCompile("a")}}.newMatcher(styles.Get("monokai"))
+
+	assert.Equal(t, chroma.KeywordNamespace+matchOffset, m.matchType(chroma.KeywordNamespace))
+	assert.Equal(t, chroma.Keyword+matchOffset, m.matchType(chroma.KeywordReserved))
+	assert.Equal(t, chroma.Text+matchOffset, m.matchType(chroma.Whitespace))
+}
+
+func TestPatterns(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile("main")}
+	const match = "\x1b[1m\x1b[38;5;16m\x1b[48;5;220mmain\x1b[0m"
+
+	t.Run("overlay", func(t *testing.T) {
+		count := 0
+		out := highlightString(t, "package main\n", Options{Theme: "monokai", Language: "go", Patterns: patterns, OnMatch: func() { count++ }})
+
+		assert.Equal(t, "\x1b[38;5;197mpackage\x1b[0m\x1b[38;5;231m \x1b[0m"+match+"\x1b[38;5;231m\x1b[0m\n", out)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("highlight lines", func(t *testing.T) {
+		out := highlightString(t, "package main\n", Options{Theme: "monokai", Language: "go", Patterns: patterns, HighlightLines: []LineRange{{Start: 1}}})
+
+		assert.Contains(t, out, "\x1b[38;5;231m\x1b[48;5;237m \x1b[0m"+match+"\x1b[38;5;231m\x1b[48;5;237m\x1b[K")
+	})
+
+	t.Run("line ranges", func(t *testing.T) {
+		count := 0
+		out := highlightString(t, "main\nmain\nmain\n", Options{Plain: true, LineRanges: []LineRange{{Start: 2}}, Patterns: patterns, OnMatch: func() { count++ }})
+
+		assert.Equal(t, "main\nmain\n", out)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		count := 0
+		out := highlightString(t, "main(main)\n", Options{Plain: true, Patterns: patterns, OnMatch: func() { count++ }})
+
+		assert.Equal(t, "main(main)\n", out)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("lines", func(t *testing.T) {
+		count := 0
+		highlightString(t, "a\nb\n", Options{Plain: true, Patterns: []*regexp.Regexp{regexp.MustCompile("^.$")}, OnMatch: func() { count++ }})
+
+		assert.Equal(t, 2, count)
+	})
+}
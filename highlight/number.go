@@ -0,0 +1,106 @@
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// NumberWriter prefixes each line written to it with its line number, like `cat -n`.
+// Flush must be called after the last write.
+type NumberWriter struct {
+	// Link returns the URL which the number of a line links to with an OSC 8 hyperlink,
+	// or "" for no link. It's optional.
+	Link func(line int) string
+
+	w           io.Writer
+	currentLine uint64
+	buf         []byte
+	// selective is set when only some lines are written, so that an empty last line is
+	// left without a number, as it's either not selected or not a line at all.
+	selective bool
+}
+
+// NewNumberWriter returns a NumberWriter writing to w, starting from line 1.
+func NewNumberWriter(w io.Writer) *NumberWriter {
+	return &NumberWriter{
+		w:           w,
+		currentLine: 1,
+	}
+}
+
+func (w *NumberWriter) Write(p []byte) (n int, err error) {
+	// Early return.
+	// Can't calculate the line numbers until the line breaks are made, so store them all in a buffer.
+	if !bytes.Contains(p, []byte{'\n'}) {
+		w.buf = append(w.buf, p...)
+		return len(p), nil
+	}
+
+	var (
+		original = p
+		tokenLen uint
+	)
+	for i, c := range original {
+		tokenLen++
+		if c != '\n' {
+			continue
+		}
+
+		token := p[:tokenLen]
+		p = original[i+1:]
+		tokenLen = 0
+
+		_, er := fmt.Fprintf(w.w, "%s%s%s", w.prefix(), string(w.buf), string(token))
+		// The line is dropped even on errors, which the formatters don't stop at.
+		w.buf = w.buf[:0]
+		if er != nil {
+			return i + 1, er
+		}
+		w.currentLine++
+	}
+
+	if len(p) > 0 {
+		w.buf = append(w.buf, p...)
+	}
+	return len(original), nil
+}
+
+// Flush writes the last line, which has no line break.
+func (w *NumberWriter) Flush() error {
+	terminalReset := []byte("\u001B[0m")
+	if bytes.Equal(w.buf, terminalReset) {
+		// In almost all cases, a control code is passed last to reset the terminal's color code.
+		// This is not a printable character and should not be counted as a line, so it is output as is without a line number.
+		_, err := fmt.Fprintf(w.w, "%s", string(w.buf))
+		return err
+	}
+	if w.selective && len(w.buf) == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(w.w, "%s%s", w.prefix(), string(w.buf))
+	w.buf = w.buf[:0]
+	return err
+}
+
+// prefix returns the line number of the current line and the tab following it.
+func (w *NumberWriter) prefix() string {
+	format := "%6d"
+	if w.currentLine > 999999 {
+		format = "%d"
+	}
+	number := fmt.Sprintf(format, w.currentLine)
+	if w.Link != nil {
+		if url := w.Link(int(w.currentLine)); url != "" {
+			number = Hyperlink(url, number)
+		}
+	}
+	return number + "\t"
+}
+
+// Hyperlink returns text wrapped in an OSC 8 hyperlink to url, which supporting terminals
+// make clickable.
+func Hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
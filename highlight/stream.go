@@ -0,0 +1,135 @@
+package highlight
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+const (
+	// chunkSize is the size at which a chunk is closed at the next blank line.
+	chunkSize = 64 * 1024
+	// maxChunkSize is the size at which a chunk is closed at the next line break,
+	// or immediately when a single line is longer than this.
+	maxChunkSize = 4 * chunkSize
+)
+
+// chunkReader splits its input into chunks of whole lines, so that large inputs can be
+// highlighted incrementally with bounded memory.
+//
+// Lexers start from their root state for each chunk, so chunks are closed at a blank line
+// where boundary tells that the lexer is back in its root state, or at maxChunkSize.
+type chunkReader struct {
+	r   *bufio.Reader
+	buf []byte
+	// boundary reports whether a chunk may be closed after its data. It's optional.
+	boundary func(chunk []byte) bool
+	// nextCheck is the size from which boundary is called again. Each call may lex the
+	// whole chunk, so a lexer which doesn't come back to its root state is only checked
+	// again after another chunkSize of input.
+	nextCheck int
+	// flushOnIdle closes a chunk as soon as no more input is buffered, so that output
+	// from slow producers such as `tail -f` is not held back. Such a chunk may end out
+	// of the root state, which the caller has to carry over to the next one.
+	flushOnIdle bool
+}
+
+func newChunkReader(r io.Reader) *chunkReader {
+	return &chunkReader{
+		r:           bufio.NewReaderSize(r, chunkSize),
+		flushOnIdle: !isRegularFile(r),
+	}
+}
+
+// Next returns the next chunk of input. At the end of the input, it returns the remaining
+// data, which may be empty, together with io.EOF.
+// The returned slice is only valid until the next call to Next.
+func (c *chunkReader) Next() ([]byte, error) {
+	c.buf = c.buf[:0]
+	c.nextCheck = chunkSize
+	lineStart := 0
+	for {
+		fragment, err := c.r.ReadSlice('\n')
+		c.buf = append(c.buf, fragment...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			if len(c.buf) >= maxChunkSize {
+				return c.buf, nil
+			}
+			continue
+		}
+		if err != nil {
+			return c.buf, err
+		}
+
+		line := c.buf[lineStart:]
+		lineStart = len(c.buf)
+		switch {
+		case len(c.buf) >= maxChunkSize:
+			return c.buf, nil
+		case len(c.buf) >= c.nextCheck && len(bytes.TrimSpace(line)) == 0 && c.atBoundary():
+			return c.buf, nil
+		case c.flushOnIdle && c.r.Buffered() == 0:
+			return c.buf, nil
+		}
+	}
+}
+
+func (c *chunkReader) atBoundary() bool {
+	if c.boundary == nil || c.boundary(c.buf) {
+		return true
+	}
+	c.nextCheck = len(c.buf) + chunkSize
+	return false
+}
+
+// rootStateTokens tokenises text, and reports whether the lexer is likely back in its
+// root state at the end of it: text ends with a line break in a text token and has no
+// errors, which lexers emit for constructs cut off in the middle. A line break in a string
+// or a comment is in a token of that type instead.
+// The tokens are nil only when text fails to tokenise.
+func rootStateTokens(lexer chroma.Lexer, text string) ([]chroma.Token, bool) {
+	iterator, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		return nil, false
+	}
+	tokens := iterator.Tokens()
+	if len(tokens) == 0 {
+		return tokens, true
+	}
+	for _, token := range tokens {
+		if token.Type == chroma.Error {
+			return tokens, false
+		}
+	}
+	last := tokens[len(tokens)-1]
+	return tokens, last.Type.InCategory(chroma.Text) && strings.HasSuffix(last.Value, "\n")
+}
+
+// skipTokens returns the tokens of the text after its first n bytes.
+func skipTokens(tokens []chroma.Token, n int) []chroma.Token {
+	for len(tokens) > 0 && n > 0 {
+		if size := len(tokens[0].Value); size > n {
+			tokens[0].Value = tokens[0].Value[n:]
+			break
+		}
+		n -= len(tokens[0].Value)
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+// isRegularFile reports whether r is a regular file, whose content is available without
+// waiting for a producer.
+func isRegularFile(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode().IsRegular()
+}
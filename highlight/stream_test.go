@@ -0,0 +1,213 @@
+package highlight
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toshimaru/nyan/styles"
+)
+
+func readChunks(t *testing.T, c *chunkReader) []string {
+	t.Helper()
+	var chunks []string
+	for {
+		chunk, err := c.Next()
+		if len(chunk) > 0 {
+			chunks = append(chunks, string(chunk))
+		}
+		if err == io.EOF {
+			return chunks
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	t.Run("small input", func(t *testing.T) {
+		c := newChunkReader(strings.NewReader("line1\nline2"))
+		c.flushOnIdle = false
+
+		assert.Equal(t, []string{"line1\nline2"}, readChunks(t, c))
+	})
+
+	t.Run("split at blank line", func(t *testing.T) {
+		block := strings.Repeat("x", chunkSize) + "\n"
+		input := block + "\n" + "next\n"
+		c := newChunkReader(strings.NewReader(input))
+		c.flushOnIdle = false
+
+		chunks := readChunks(t, c)
+		assert.Equal(t, []string{block + "\n", "next\n"}, chunks)
+	})
+
+	t.Run("blank line out of boundary", func(t *testing.T) {
+		block := strings.Repeat("x", chunkSize) + "\n"
+		// After a blank line out of the boundary, the next check waits for another chunkSize.
+		input := block + "\n" + "early\n\n" + block + "inside\n\n" + "next\n"
+		c := newChunkReader(strings.NewReader(input))
+		c.flushOnIdle = false
+		c.boundary = func(chunk []byte) bool {
+			return bytes.HasSuffix(chunk, []byte("early\n\n")) || bytes.HasSuffix(chunk, []byte("inside\n\n"))
+		}
+
+		chunks := readChunks(t, c)
+		assert.Equal(t, []string{block + "\n" + "early\n\n" + block + "inside\n\n", "next\n"}, chunks)
+	})
+
+	t.Run("never at boundary", func(t *testing.T) {
+		input := strings.Repeat("x\n\n", 2*maxChunkSize)
+		c := newChunkReader(strings.NewReader(input))
+		c.flushOnIdle = false
+		calls := 0
+		c.boundary = func(chunk []byte) bool {
+			calls++
+			return false
+		}
+
+		chunks := readChunks(t, c)
+		assert.Equal(t, input, strings.Join(chunks, ""))
+		// The boundary is checked once per chunkSize of input after the first chunkSize.
+		assert.LessOrEqual(t, calls, len(chunks)*(maxChunkSize/chunkSize))
+	})
+
+	t.Run("split long line", func(t *testing.T) {
+		input := strings.Repeat("x", maxChunkSize+10)
+		c := newChunkReader(strings.NewReader(input))
+		c.flushOnIdle = false
+
+		chunks := readChunks(t, c)
+		require.Len(t, chunks, 2)
+		assert.Len(t, chunks[0], maxChunkSize)
+		assert.Equal(t, input, strings.Join(chunks, ""))
+	})
+
+	t.Run("flush on idle", func(t *testing.T) {
+		r, w := io.Pipe()
+		c := newChunkReader(r)
+		go func() {
+			w.Write([]byte("first\n"))
+			w.Write([]byte("second\n"))
+			w.Close()
+		}()
+
+		assert.Equal(t, []string{"first\n", "second\n"}, readChunks(t, c))
+	})
+}
+
+func TestRootStateTokens(t *testing.T) {
+	tests := []struct {
+		language string
+		text     string
+		want     bool
+	}{
+		{"python", "x = 1\n\n", true},
+		{"python", "def f():\n    \"\"\"doc\n\n", false},
+		{"go", "func f() {\n}\n\n", true},
+		{"go", "x := `raw\n\n", false},
+		{"c", "/* comment\n\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language+" "+tt.text, func(t *testing.T) {
+			tokens, ok := rootStateTokens(lexers.Get(tt.language), tt.text)
+
+			assert.Equal(t, tt.want, ok)
+			assert.Equal(t, tt.text, tokensText(tokens))
+		})
+	}
+}
+
+func TestHighlightAcrossChunks(t *testing.T) {
+	var b strings.Builder
+	for b.Len() < chunkSize+2048 {
+		b.WriteString("x = '" + strings.Repeat("filler ", 50) + "'\n")
+	}
+	b.WriteString("def f():\n    \"\"\"doc\n\n    body\n    \"\"\"\n    return 1\n\n")
+	for range 100 {
+		b.WriteString("y = 'text'\n")
+	}
+	text := b.String()
+
+	out := highlightString(t, text, Options{Theme: "monokai", Language: "python"})
+
+	var want bytes.Buffer
+	iterator, err := lexers.Get("python").Tokenise(nil, text)
+	require.NoError(t, err)
+	require.NoError(t, formatters.Get("terminal256").Format(&want, styles.Get("monokai"), iterator))
+	assert.Equal(t, want.String(), out)
+}
+
+func TestHighlightOutOfRootState(t *testing.T) {
+	// A JSON array with blank lines in it never leaves the array state at a blank line.
+	var b strings.Builder
+	b.WriteString("[\n")
+	for b.Len() < maxChunkSize+chunkSize {
+		b.WriteString("  {\"key\": \"value\", \"number\": 1},\n\n")
+	}
+	b.WriteString("  {}\n]\n")
+
+	done := make(chan string)
+	go func() {
+		var out bytes.Buffer
+		assert.NoError(t, Highlight(&out, strings.NewReader(b.String()), Options{Theme: "monokai", Language: "json"}))
+		done <- out.String()
+	}()
+	select {
+	case out := <-done:
+		assert.Contains(t, out, "value")
+	case <-time.After(30 * time.Second):
+		t.Fatal("highlighting took too long")
+	}
+}
+
+func TestHighlightIdleChunks(t *testing.T) {
+	lines := []string{"int x;\n", "/* a comment\n", "   int y;\n", "*/\n", "int z;\n"}
+	r, w := io.Pipe()
+	go func() {
+		// Each write is read alone, so that each line is closed as a chunk of its own.
+		for _, line := range lines {
+			w.Write([]byte(line))
+		}
+		w.Close()
+	}()
+
+	var out bytes.Buffer
+	require.NoError(t, Highlight(&out, r, Options{Theme: "monokai", Language: "c"}))
+
+	var want bytes.Buffer
+	iterator, err := lexers.Get("c").Tokenise(nil, strings.Join(lines, ""))
+	require.NoError(t, err)
+	require.NoError(t, formatters.Get("terminal256").Format(&want, styles.Get("monokai"), iterator))
+	assert.Equal(t, want.String(), out.String())
+}
+
+func TestSkipTokens(t *testing.T) {
+	tokens := []chroma.Token{
+		{Type: chroma.Keyword, Value: "int"},
+		{Type: chroma.Text, Value: " "},
+		{Type: chroma.Name, Value: "x"},
+	}
+
+	assert.Equal(t, []chroma.Token{{Type: chroma.Text, Value: " "}, {Type: chroma.Name, Value: "x"}}, skipTokens(slices.Clone(tokens), 3))
+	assert.Equal(t, []chroma.Token{{Type: chroma.Keyword, Value: "t"}, {Type: chroma.Text, Value: " "}, {Type: chroma.Name, Value: "x"}}, skipTokens(slices.Clone(tokens), 2))
+	assert.Equal(t, tokens, skipTokens(slices.Clone(tokens), 0))
+	assert.Empty(t, skipTokens(slices.Clone(tokens), 5))
+}
+
+func TestIsRegularFile(t *testing.T) {
+	f, err := os.Open("stream.go")
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.True(t, isRegularFile(f))
+	assert.False(t, isRegularFile(&bytes.Buffer{}))
+}
@@ -15,4 +15,5 @@ var Abap = Register(chroma.MustNewStyle("abap", chroma.StyleEntries{
 	chroma.LiteralString:  "#5a2",
 	chroma.Error:          "#F00",
 	// chroma.Background:     " bg:#ffffff",
+	chroma.LineHighlight: "bg:#e5e5e5",
 }))
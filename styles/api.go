@@ -28,6 +28,12 @@ func Names() []string {
 	return out
 }
 
+// Lookup a named style. Unlike Get, it reports unknown names instead of returning Fallback.
+func Lookup(name string) (*chroma.Style, bool) {
+	style, ok := Registry[name]
+	return style, ok
+}
+
 // Get named style, or Fallback.
 func Get(name string) *chroma.Style {
 	if style, ok := Registry[name]; ok {
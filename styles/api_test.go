@@ -31,3 +31,13 @@ func TestRegister(t *testing.T) {
 
 	assert.Equal(t, "newstyle", style.Name)
 }
+
+func TestLookup(t *testing.T) {
+	style, ok := Lookup("vim")
+	assert.True(t, ok)
+	assert.Equal(t, "vim", style.Name)
+
+	style, ok = Lookup("invalid-style")
+	assert.False(t, ok)
+	assert.Nil(t, style)
+}
@@ -78,4 +78,5 @@ var Dracula = Register(chroma.MustNewStyle("dracula", chroma.StyleEntries{
 	chroma.Text:                     "#f8f8f2",
 	chroma.TextWhitespace:           "#f8f8f2",
 	//chroma.Background:               "bg:#282a36",
+	chroma.LineHighlight: "bg:#3d3f4a",
 }))
@@ -48,4 +48,5 @@ var Emacs = Register(chroma.MustNewStyle("emacs", chroma.StyleEntries{
 	chroma.GenericUnderline:      "underline",
 	chroma.Error:                 "border:#FF0000",
 	// chroma.Background:            " bg:#f8f8f8",
+	chroma.LineHighlight: "bg:#dfdfdf",
 }))
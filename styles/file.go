@@ -0,0 +1,204 @@
+package styles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported formats of style files.
+const (
+	FormatXML  = "xml"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// styleFile is the JSON and YAML representation of a style.
+//
+//	name: house
+//	entries:
+//	  Keyword: "bold #ff79c6"
+//	  Comment: "italic #6272a4"
+type styleFile struct {
+	Name    string            `json:"name" yaml:"name"`
+	Entries map[string]string `json:"entries" yaml:"entries"`
+}
+
+// Load a style in the given format from r.
+// The name is used when the style data doesn't have one.
+func Load(r io.Reader, name, format string) (*chroma.Style, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file styleFile
+	switch format {
+	case FormatXML:
+		return chroma.NewXMLStyle(bytes.NewReader(data))
+	case FormatJSON:
+		err = json.Unmarshal(data, &file)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("unsupported style format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Name == "" {
+		file.Name = name
+	}
+	entries := chroma.StyleEntries{}
+	for key, value := range file.Entries {
+		ttype, err := chroma.TokenTypeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("unknown token type %q", key)
+		}
+		entries[ttype] = value
+	}
+	return chroma.NewStyle(file.Name, entries)
+}
+
+// LoadFile loads a style file. The format is chosen by its extension
+// (.xml, .json, .yaml or .yml), and the file name without the extension is
+// used when the style data doesn't have a name.
+func LoadFile(path string) (*chroma.Style, error) {
+	ext := filepath.Ext(path)
+	format, ok := FormatOf(path)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported style file extension %q", path, ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	style, err := Load(f, strings.TrimSuffix(filepath.Base(path), ext), format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return style, nil
+}
+
+// FormatOf returns the style file format of path according to its extension.
+func FormatOf(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return FormatXML, true
+	case ".json":
+		return FormatJSON, true
+	case ".yaml", ".yml":
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+// RegisterFile loads a style file and registers it.
+func RegisterFile(path string) (*chroma.Style, error) {
+	style, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Register(style), nil
+}
+
+// RegisterDir registers all style files in dir, as loaded by LoadDir.
+func RegisterDir(dir string) ([]*chroma.Style, error) {
+	loaded, err := LoadDir(dir)
+	for _, style := range loaded {
+		Register(style)
+	}
+	return loaded, err
+}
+
+// LoadDir loads all style files in dir without registering them. A missing dir is not
+// an error. Files which fail to load are skipped, and their errors are joined in the
+// returned error.
+func LoadDir(dir string) ([]*chroma.Style, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out  []*chroma.Style
+		errs []error
+	)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := FormatOf(entry.Name()); !ok {
+			continue
+		}
+		style, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out = append(out, style)
+	}
+	return out, errors.Join(errs...)
+}
+
+// xmlStyle is used to read back the entries of a style marshalled by chroma.
+type xmlStyle struct {
+	Name    string `xml:"name,attr"`
+	Entries []struct {
+		Type  string `xml:"type,attr"`
+		Style string `xml:"style,attr"`
+	} `xml:"entry"`
+}
+
+// Save writes a style in the given format, so that it can be read back with Load.
+func Save(w io.Writer, style *chroma.Style, format string) error {
+	data, err := xml.MarshalIndent(style, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == FormatXML {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	var parsed xmlStyle
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	file := styleFile{Name: parsed.Name, Entries: map[string]string{}}
+	for _, entry := range parsed.Entries {
+		file.Entries[entry.Type] = entry.Style
+	}
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(file)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(file); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unsupported style format %q", format)
+}
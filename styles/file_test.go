@@ -0,0 +1,115 @@
+package styles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		style, err := Load(strings.NewReader(`{"entries": {"Keyword": "bold #ff0000"}}`), "default-name", FormatJSON)
+
+		require.NoError(t, err)
+		assert.Equal(t, "default-name", style.Name)
+		assert.Equal(t, chroma.Yes, style.Get(chroma.Keyword).Bold)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		style, err := Load(strings.NewReader("name: custom\nentries:\n  Comment: italic #888888\n"), "default-name", FormatYAML)
+
+		require.NoError(t, err)
+		assert.Equal(t, "custom", style.Name)
+		assert.Equal(t, chroma.Yes, style.Get(chroma.Comment).Italic)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		style, err := Load(strings.NewReader(`<style name="custom"><entry type="Keyword" style="#ff0000"/></style>`), "default-name", FormatXML)
+
+		require.NoError(t, err)
+		assert.Equal(t, "custom", style.Name)
+		assert.Equal(t, "#ff0000", style.Get(chroma.Keyword).Colour.String())
+	})
+
+	t.Run("unknown token type", func(t *testing.T) {
+		_, err := Load(strings.NewReader(`{"entries": {"NoSuchToken": "#ff0000"}}`), "x", FormatJSON)
+
+		assert.EqualError(t, err, `unknown token type "NoSuchToken"`)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Load(strings.NewReader(""), "x", "toml")
+
+		assert.EqualError(t, err, `unsupported style format "toml"`)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	for _, path := range []string{"testdata/themes/house-xml.xml", "testdata/themes/house-json.json", "testdata/themes/house-yaml.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			style, err := LoadFile(path)
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(style.Name, "house-"))
+			assert.Equal(t, "#ff0000", style.Get(chroma.Keyword).Colour.String())
+			assert.Equal(t, "#000000", style.Get(chroma.Background).Background.String())
+		})
+	}
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := LoadFile("testdata/themes/README.txt")
+
+		assert.EqualError(t, err, `testdata/themes/README.txt: unsupported style file extension ".txt"`)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadFile("testdata/themes/missing.json")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRegisterDir(t *testing.T) {
+	t.Cleanup(func() {
+		for _, name := range []string{"house-xml", "house-json", "house-yaml", "valid"} {
+			delete(Registry, name)
+		}
+	})
+
+	t.Run("valid themes", func(t *testing.T) {
+		registered, err := RegisterDir("testdata/themes")
+
+		require.NoError(t, err)
+		assert.Len(t, registered, 3)
+		assert.Contains(t, Names(), "house-json")
+		assert.Contains(t, Names(), "house-xml")
+		assert.Contains(t, Names(), "house-yaml")
+	})
+
+	t.Run("broken theme", func(t *testing.T) {
+		registered, err := RegisterDir("testdata/broken")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown-token.json")
+		require.Len(t, registered, 1)
+		assert.Equal(t, "valid", registered[0].Name)
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		registered, err := RegisterDir("testdata/missing")
+
+		assert.NoError(t, err)
+		assert.Empty(t, registered)
+	})
+}
+
+func TestLoadDir(t *testing.T) {
+	loaded, err := LoadDir("testdata/themes")
+
+	require.NoError(t, err)
+	assert.Len(t, loaded, 3)
+	assert.NotContains(t, Names(), "house-json")
+}
@@ -0,0 +1,446 @@
+package styles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// scopeMap maps chroma token types onto the TextMate scopes they correspond to.
+// A theme rule applies to a token type when one of its scope selectors matches one of these scopes.
+var scopeMap = map[chroma.TokenType][]string{
+	chroma.Comment:             {"comment"},
+	chroma.CommentPreproc:      {"meta.preprocessor", "keyword.control.directive"},
+	chroma.Keyword:             {"keyword", "keyword.control"},
+	chroma.KeywordConstant:     {"constant.language"},
+	chroma.KeywordDeclaration:  {"storage", "storage.type.function"},
+	chroma.KeywordNamespace:    {"keyword.control.import", "keyword.other.import"},
+	chroma.KeywordType:         {"storage.type", "support.type"},
+	chroma.Operator:            {"keyword.operator"},
+	chroma.Punctuation:         {"punctuation"},
+	chroma.NameAttribute:       {"entity.other.attribute-name"},
+	chroma.NameBuiltin:         {"support.function"},
+	chroma.NameClass:           {"entity.name.type.class", "entity.name.class", "entity.name.type"},
+	chroma.NameConstant:        {"variable.other.constant", "constant.other"},
+	chroma.NameDecorator:       {"entity.name.function.decorator", "meta.decorator"},
+	chroma.NameException:       {"support.class.exception", "entity.name.exception"},
+	chroma.NameFunction:        {"entity.name.function"},
+	chroma.NameNamespace:       {"entity.name.namespace", "entity.name.package"},
+	chroma.NameTag:             {"entity.name.tag"},
+	chroma.NameVariable:        {"variable.other", "variable"},
+	chroma.Literal:             {"constant"},
+	chroma.LiteralNumber:       {"constant.numeric"},
+	chroma.LiteralString:       {"string"},
+	chroma.LiteralStringEscape: {"constant.character.escape"},
+	chroma.LiteralStringRegex:  {"string.regexp"},
+	chroma.GenericDeleted:      {"markup.deleted"},
+	chroma.GenericEmph:         {"markup.italic"},
+	chroma.GenericHeading:      {"markup.heading"},
+	chroma.GenericInserted:     {"markup.inserted"},
+	chroma.GenericStrong:       {"markup.bold"},
+	chroma.GenericSubheading:   {"markup.heading.2"},
+	chroma.Error:               {"invalid"},
+}
+
+// themeRule is a scoped rule of an editor theme.
+type themeRule struct {
+	selectors  []string
+	foreground string
+	background string
+	fontStyle  string
+}
+
+// editorTheme is the common part of TextMate and VS Code themes.
+type editorTheme struct {
+	name          string
+	foreground    string
+	background    string
+	lineHighlight string
+	rules         []themeRule
+}
+
+// ImportTmTheme converts a TextMate or Sublime Text .tmTheme file into a style.
+// The name is used when the theme doesn't have one.
+func ImportTmTheme(r io.Reader, name string) (*chroma.Style, error) {
+	root, err := decodePlist(r)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("tmTheme: root element is not a dict")
+	}
+
+	theme := editorTheme{name: name}
+	if s, ok := dict["name"].(string); ok && s != "" {
+		theme.name = s
+	}
+	settings, _ := dict["settings"].([]any)
+	for _, item := range settings {
+		setting, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		values, _ := setting["settings"].(map[string]any)
+		scope, _ := setting["scope"].(string)
+		if scope == "" {
+			// The global settings have no scope.
+			theme.foreground, _ = values["foreground"].(string)
+			theme.background, _ = values["background"].(string)
+			theme.lineHighlight, _ = values["lineHighlight"].(string)
+			continue
+		}
+		rule := themeRule{selectors: splitSelectors(scope)}
+		rule.foreground, _ = values["foreground"].(string)
+		rule.background, _ = values["background"].(string)
+		rule.fontStyle, _ = values["fontStyle"].(string)
+		theme.rules = append(theme.rules, rule)
+	}
+	return theme.style()
+}
+
+// vscodeTheme is the JSON representation of a VS Code color theme.
+type vscodeTheme struct {
+	Name        string            `json:"name"`
+	Colors      map[string]string `json:"colors"`
+	TokenColors []struct {
+		Scope    json.RawMessage `json:"scope"`
+		Settings struct {
+			Foreground string `json:"foreground"`
+			Background string `json:"background"`
+			FontStyle  string `json:"fontStyle"`
+		} `json:"settings"`
+	} `json:"tokenColors"`
+}
+
+// ImportVSCode converts a VS Code JSON color theme into a style.
+// The name is used when the theme doesn't have one.
+func ImportVSCode(r io.Reader, name string) (*chroma.Style, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var vscode vscodeTheme
+	if err := json.Unmarshal(stripJSONC(data), &vscode); err != nil {
+		return nil, fmt.Errorf("vscode theme: %w", err)
+	}
+
+	theme := editorTheme{
+		name:          name,
+		foreground:    vscode.Colors["editor.foreground"],
+		background:    vscode.Colors["editor.background"],
+		lineHighlight: vscode.Colors["editor.lineHighlightBackground"],
+	}
+	if vscode.Name != "" {
+		theme.name = vscode.Name
+	}
+	for _, tokenColor := range vscode.TokenColors {
+		var selectors []string
+		var scope string
+		var scopes []string
+		switch {
+		case json.Unmarshal(tokenColor.Scope, &scope) == nil:
+			selectors = splitSelectors(scope)
+		case json.Unmarshal(tokenColor.Scope, &scopes) == nil:
+			selectors = splitSelectors(strings.Join(scopes, ","))
+		}
+		if len(selectors) == 0 {
+			if theme.foreground == "" {
+				theme.foreground = tokenColor.Settings.Foreground
+			}
+			if theme.background == "" {
+				theme.background = tokenColor.Settings.Background
+			}
+			continue
+		}
+		theme.rules = append(theme.rules, themeRule{
+			selectors:  selectors,
+			foreground: tokenColor.Settings.Foreground,
+			background: tokenColor.Settings.Background,
+			fontStyle:  tokenColor.Settings.FontStyle,
+		})
+	}
+	return theme.style()
+}
+
+// ImportFile converts an editor theme file into a style. The format is chosen by its
+// extension: .tmTheme for TextMate and Sublime Text, and .json for VS Code.
+// The file name without the extension is used when the theme doesn't have a name.
+func ImportFile(path string) (*chroma.Style, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	var style *chroma.Style
+	switch strings.ToLower(ext) {
+	case ".tmtheme":
+		style, err = ImportTmTheme(f, name)
+	case ".json":
+		style, err = ImportVSCode(f, name)
+	default:
+		return nil, fmt.Errorf("%s: unsupported theme file extension %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return style, nil
+}
+
+// style builds a chroma.Style by picking, for each token type, the most specific rule
+// matching its scopes. Among equally specific rules, the later one wins as in TextMate.
+func (t editorTheme) style() (*chroma.Style, error) {
+	if t.name == "" {
+		return nil, fmt.Errorf("theme has no name")
+	}
+
+	entries := chroma.StyleEntries{}
+	if entry := t.styleEntry(t.foreground, t.background, ""); entry != "" {
+		entries[chroma.Background] = entry
+	}
+	if entry := t.styleEntry(t.foreground, "", ""); entry != "" {
+		entries[chroma.Text] = entry
+	}
+	if entry := t.styleEntry("", t.lineHighlight, ""); entry != "" {
+		entries[chroma.LineHighlight] = entry
+	}
+
+	for ttype, scopes := range scopeMap {
+		var (
+			best      *themeRule
+			bestScore int
+		)
+		for i := range t.rules {
+			rule := &t.rules[i]
+			for _, scope := range scopes {
+				for _, selector := range rule.selectors {
+					if score := matchScope(selector, scope); score > 0 && score >= bestScore {
+						best, bestScore = rule, score
+					}
+				}
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if entry := t.styleEntry(best.foreground, best.background, best.fontStyle); entry != "" {
+			entries[ttype] = entry
+		}
+	}
+	return chroma.NewStyle(t.name, entries)
+}
+
+// matchScope returns how specifically selector matches scope, or 0 if it doesn't match.
+// A selector matches a scope equal to it, or a scope it is a dot-separated prefix of.
+func matchScope(selector, scope string) int {
+	if selector == scope || strings.HasPrefix(scope, selector+".") {
+		return strings.Count(selector, ".") + 1
+	}
+	return 0
+}
+
+// splitSelectors splits a comma-separated scope selector into the scopes it targets.
+// Only the last element of descendant selectors ("source.go string") is kept, and
+// exclusions ("string - string.quoted") are dropped.
+func splitSelectors(scope string) []string {
+	var out []string
+	for _, selector := range strings.Split(scope, ",") {
+		selector, _, _ = strings.Cut(selector, " -")
+		fields := strings.Fields(selector)
+		if len(fields) == 0 {
+			continue
+		}
+		out = append(out, fields[len(fields)-1])
+	}
+	return out
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// styleEntry builds a chroma style entry string from editor theme settings.
+func (t editorTheme) styleEntry(foreground, background, fontStyle string) string {
+	var parts []string
+	for _, style := range strings.Fields(fontStyle) {
+		switch style {
+		case "bold", "italic", "underline":
+			parts = append(parts, style)
+		}
+	}
+	base := color(t.background, "")
+	if c := color(foreground, base); c != "" {
+		parts = append(parts, c)
+	}
+	if c := color(background, base); c != "" {
+		parts = append(parts, "bg:"+c)
+	}
+	return strings.Join(parts, " ")
+}
+
+// color normalises an editor theme color to #rrggbb. Chroma doesn't support transparency,
+// so a color with an alpha channel is blended over base, the theme background. Without a
+// base, mostly transparent colors are dropped, and the others are taken as opaque.
+func color(c, base string) string {
+	if !hexColor.MatchString(c) {
+		return ""
+	}
+	hex := strings.ToLower(c[1:])
+	if len(hex) <= 4 { // #RGB or #RGBA
+		var long strings.Builder
+		for _, digit := range hex {
+			long.WriteString(strings.Repeat(string(digit), 2))
+		}
+		hex = long.String()
+	}
+	if len(hex) == 6 {
+		return "#" + hex
+	}
+
+	alpha, _ := strconv.ParseUint(hex[6:], 16, 8)
+	if base == "" {
+		if alpha < 0x80 {
+			return ""
+		}
+		return "#" + hex[:6]
+	}
+	var out strings.Builder
+	out.WriteByte('#')
+	for i := 0; i < 6; i += 2 {
+		over, _ := strconv.ParseUint(hex[i:i+2], 16, 8)
+		under, _ := strconv.ParseUint(base[i+1:i+3], 16, 8)
+		fmt.Fprintf(&out, "%02x", (over*alpha+under*(0xff-alpha)+0x7f)/0xff)
+	}
+	return out.String()
+}
+
+// stripJSONC removes comments and trailing commas from JSON with comments,
+// the format VS Code uses for its theme files.
+func stripJSONC(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+		case c == ']' || c == '}':
+			trimmed := bytes.TrimRight(out.Bytes(), " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out.Truncate(len(trimmed) - 1)
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+// decodePlist decodes an XML property list into maps, slices, strings, numbers and booleans.
+func decodePlist(r io.Reader) (any, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			if el.Name.Local == "plist" {
+				continue
+			}
+			return decodePlistValue(dec, el)
+		}
+	}
+}
+
+func decodePlistValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]any{}
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("plist: %w", err)
+			}
+			switch el := tok.(type) {
+			case xml.StartElement:
+				if el.Name.Local == "key" {
+					if err := dec.DecodeElement(&key, &el); err != nil {
+						return nil, fmt.Errorf("plist: %w", err)
+					}
+					continue
+				}
+				value, err := decodePlistValue(dec, el)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []any
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("plist: %w", err)
+			}
+			switch el := tok.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(dec, el)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		return start.Name.Local == "true", nil
+	default:
+		// string, integer, real, date and data are kept as their text.
+		var text string
+		if err := dec.DecodeElement(&text, &start); err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		return strings.TrimSpace(text), nil
+	}
+}
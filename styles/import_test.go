@@ -0,0 +1,139 @@
+package styles
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportTmTheme(t *testing.T) {
+	style, err := ImportFile("testdata/import/sample.tmTheme")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sample Tm", style.Name)
+	assert.Equal(t, "#272822", style.Get(chroma.Background).Background.String())
+	assert.Equal(t, "#f8f8f2", style.Get(chroma.Text).Colour.String())
+	assert.Equal(t, "#3e3d32", style.Get(chroma.LineHighlight).Background.String())
+	assert.Equal(t, "#75715e", style.Get(chroma.Comment).Colour.String())
+	assert.Equal(t, chroma.Yes, style.Get(chroma.Comment).Italic)
+	assert.Equal(t, "#e6db74", style.Get(chroma.LiteralString).Colour.String())
+	assert.Equal(t, "#f92672", style.Get(chroma.Keyword).Colour.String())
+	assert.Equal(t, "#f8f8f2", style.Get(chroma.Operator).Colour.String())
+	// #A6E22E80 blended over the background.
+	assert.Equal(t, "#678528", style.Get(chroma.NameFunction).Colour.String())
+	assert.Equal(t, chroma.Yes, style.Get(chroma.NameFunction).Bold)
+	assert.Equal(t, chroma.Yes, style.Get(chroma.NameFunction).Underline)
+	assert.Equal(t, "#f92672", style.Get(chroma.Error).Background.String())
+}
+
+func TestImportVSCode(t *testing.T) {
+	style, err := ImportFile("testdata/import/sample-vscode.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sample VS Code", style.Name)
+	assert.Equal(t, "#1e1e1e", style.Get(chroma.Background).Background.String())
+	assert.Equal(t, "#d4d4d4", style.Get(chroma.Text).Colour.String())
+	// #FFFFFF10 blended over the background.
+	assert.Equal(t, "#2c2c2c", style.Get(chroma.LineHighlight).Background.String())
+	assert.Equal(t, "#6a9955", style.Get(chroma.Comment).Colour.String())
+	assert.Equal(t, "#ce9178", style.Get(chroma.LiteralString).Colour.String())
+	assert.Equal(t, "#c586c0", style.Get(chroma.Keyword).Colour.String())
+	assert.Equal(t, "#c586c0", style.Get(chroma.KeywordType).Colour.String())
+	assert.Equal(t, "#dcdcaa", style.Get(chroma.NameFunction).Colour.String())
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		color, base, want string
+	}{
+		{"#1E1E1E", "", "#1e1e1e"},
+		{"#abc", "", "#aabbcc"},
+		{"#FFFFFF10", "#1e1e1e", "#2c2c2c"},
+		{"#FFFFFF80", "#000000", "#808080"},
+		{"#fff8", "#000000", "#888888"},
+		{"#FFFFFF10", "", ""},
+		{"#FFFFFFC0", "", "#ffffff"},
+		{"red", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color+" over "+tt.base, func(t *testing.T) {
+			assert.Equal(t, tt.want, color(tt.color, tt.base))
+		})
+	}
+}
+
+func TestImportFileErrors(t *testing.T) {
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := ImportFile("testdata/themes/house-xml.xml")
+
+		assert.EqualError(t, err, `testdata/themes/house-xml.xml: unsupported theme file extension ".xml"`)
+	})
+
+	t.Run("invalid plist", func(t *testing.T) {
+		_, err := ImportTmTheme(strings.NewReader("<plist><array></array></plist>"), "x")
+
+		assert.EqualError(t, err, "tmTheme: root element is not a dict")
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := ImportVSCode(strings.NewReader("{"), "x")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("name from file", func(t *testing.T) {
+		style, err := ImportVSCode(strings.NewReader(`{"tokenColors": []}`), "fallback-name")
+
+		require.NoError(t, err)
+		assert.Equal(t, "fallback-name", style.Name)
+	})
+}
+
+func TestSplitSelectors(t *testing.T) {
+	assert.Equal(t, []string{"string", "string.quoted", "comment"}, splitSelectors("string, source.go string.quoted - string.regexp,comment"))
+	assert.Empty(t, splitSelectors(" , "))
+}
+
+func TestMatchScope(t *testing.T) {
+	assert.Equal(t, 1, matchScope("keyword", "keyword.control"))
+	assert.Equal(t, 2, matchScope("keyword.control", "keyword.control"))
+	assert.Zero(t, matchScope("keyword.control", "keyword"))
+	assert.Zero(t, matchScope("key", "keyword"))
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := `{
+		// comment
+		"url": "http://example.com/*not a comment*/", /* block */
+		"list": [1, 2,],
+	}`
+
+	assert.JSONEq(t, `{"url": "http://example.com/*not a comment*/", "list": [1, 2]}`, string(stripJSONC([]byte(input))))
+}
+
+func TestSave(t *testing.T) {
+	style, err := ImportFile("testdata/import/sample.tmTheme")
+	require.NoError(t, err)
+
+	for _, format := range []string{FormatXML, FormatJSON, FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			require.NoError(t, Save(&b, style, format))
+
+			loaded, err := Load(&b, "x", format)
+			require.NoError(t, err)
+			assert.Equal(t, style.Name, loaded.Name)
+			for _, ttype := range style.Types() {
+				assert.Equal(t, style.Get(ttype), loaded.Get(ttype), ttype.String())
+			}
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.EqualError(t, Save(&bytes.Buffer{}, style, "toml"), `unsupported style format "toml"`)
+	})
+}
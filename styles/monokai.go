@@ -33,4 +33,5 @@ var Monokai = Register(chroma.MustNewStyle("monokai", chroma.StyleEntries{
 	chroma.GenericStrong:       "bold",
 	chroma.GenericSubheading:   "#75715e",
 	//chroma.Background:          "bg:#272822",
+	chroma.LineHighlight: "bg:#3c3d38",
 }))
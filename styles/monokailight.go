@@ -30,4 +30,5 @@ var MonokaiLight = Register(chroma.MustNewStyle("monokailight", chroma.StyleEntr
 	chroma.GenericEmph:         "italic",
 	chroma.GenericStrong:       "bold",
 	// chroma.Background:          " bg:#fafafa",
+	chroma.LineHighlight: "bg:#e1e1e1",
 }))
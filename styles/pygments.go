@@ -51,5 +51,6 @@ var Pygments = Register(chroma.MustNewStyle("pygments", chroma.StyleEntries{
 	chroma.GenericTraceback:  "#04D",
 	chroma.GenericUnderline:  "underline",
 
-	chroma.Error: "border:#FF0000",
+	chroma.Error:         "border:#FF0000",
+	chroma.LineHighlight: "bg:#e5e5e5",
 }))
@@ -42,5 +42,6 @@ var SolarizedDark = Register(chroma.MustNewStyle("solarized-dark", chroma.StyleE
 	chroma.GenericStrong:         "bold",
 	chroma.GenericSubheading:     "#268BD2",
 	//chroma.Background:            "#93A1A1 bg:#002B36",
-	chroma.Other: "#CB4B16",
+	chroma.LineHighlight: "bg:#19404a",
+	chroma.Other:         "#CB4B16",
 }))
@@ -21,4 +21,5 @@ var SolarizedLight = Register(chroma.MustNewStyle("solarized-light", chroma.Styl
 	chroma.Comment:          "#93a1a1 italic",
 	chroma.Generic:          "#d33682",
 	// chroma.Background:       " bg:#eee8d5",
+	chroma.LineHighlight: "bg:#e3ddcc",
 }))
@@ -7,6 +7,7 @@ import (
 // SwapOff theme.
 var SwapOff = Register(chroma.MustNewStyle("swapoff", map[chroma.TokenType]string{
 	//chroma.Background:        "#lightgray bg:#black",
+	chroma.LineHighlight:     "bg:#191919",
 	chroma.Number:            "bold #ansiyellow",
 	chroma.Comment:           "#ansiteal",
 	chroma.CommentPreproc:    "bold #ansigreen",